@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// argon2id параметрлерінің шектері. Базадағы бүлінген хэш argon2.IDKey-ді паникаға
+// (p=0) немесе жадты толтыруға әкелмеуі үшін хэштен оқылған мәндер де тексеріледі.
+const (
+	maxArgon2Time   = 64
+	maxArgon2Memory = 1024 * 1024 // KiB, яғни 1 GiB
+	minArgon2KeyLen = 4
+	maxArgon2KeyLen = 1024
+)
+
+// PasswordParams жаңа хэштер үшін қолданылатын параметрлер.
+// Параметрлер өзгерсе, ескі хэштер кіру кезінде қайта хэштеледі.
+type PasswordParams struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+	Argon2KeyLen  uint32
+	Argon2SaltLen uint32
+}
+
+var DefaultPasswordParams = PasswordParams{
+	Algorithm:     AlgorithmArgon2id,
+	BcryptCost:    12,
+	Argon2Time:    3,
+	Argon2Memory:  64 * 1024,
+	Argon2Threads: 2,
+	Argon2KeyLen:  32,
+	Argon2SaltLen: 16,
+}
+
+var passwordParams = DefaultPasswordParams
+
+func SetPasswordParams(params PasswordParams) error {
+	switch params.Algorithm {
+	case AlgorithmArgon2id:
+		if !validArgon2Params(params.Argon2Time, params.Argon2Memory, params.Argon2Threads, params.Argon2KeyLen) ||
+			params.Argon2SaltLen == 0 {
+			return errors.New("argon2id parameters are out of range")
+		}
+	case AlgorithmBcrypt:
+		if params.BcryptCost < bcrypt.MinCost || params.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return fmt.Errorf("unknown password algorithm %q", params.Algorithm)
+	}
+	passwordParams = params
+	return nil
+}
+
+func HashPassword(password string) (string, error) {
+	if passwordParams.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordParams.BcryptCost)
+		return string(hash), err
+	}
+
+	salt := make([]byte, passwordParams.Argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, passwordParams.Argon2Time, passwordParams.Argon2Memory,
+		passwordParams.Argon2Threads, passwordParams.Argon2KeyLen)
+
+	// PHC форматы: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		passwordParams.Argon2Memory, passwordParams.Argon2Time, passwordParams.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func VerifyPassword(encoded, password string) (bool, error) {
+	if isBcryptHash(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.Argon2Time, params.Argon2Memory,
+		params.Argon2Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+var dummyHash struct {
+	sync.Mutex
+	params PasswordParams
+	hash   string
+}
+
+// VerifyDummyPassword белгісіз пайдаланушы үшін VerifyPassword сияқты уақыт жұмсайды:
+// жауап уақыты бойынша логиннің бар-жоғын анықтауға болмауы үшін.
+func VerifyDummyPassword(password string) {
+	dummyHash.Lock()
+	if dummyHash.hash == "" || dummyHash.params != passwordParams {
+		hash, err := HashPassword("dummy password")
+		if err != nil {
+			dummyHash.Unlock()
+			return
+		}
+		dummyHash.params, dummyHash.hash = passwordParams, hash
+	}
+	hash := dummyHash.hash
+	dummyHash.Unlock()
+
+	_, _ = VerifyPassword(hash, password)
+}
+
+// NeedsRehash хэш ағымдағы алгоритммен не параметрлермен жасалмаған болса true қайтарады.
+func NeedsRehash(encoded string) bool {
+	if isBcryptHash(encoded) {
+		if passwordParams.Algorithm != AlgorithmBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != passwordParams.BcryptCost
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil || passwordParams.Algorithm != AlgorithmArgon2id {
+		return true
+	}
+	return params.Argon2Time != passwordParams.Argon2Time ||
+		params.Argon2Memory != passwordParams.Argon2Memory ||
+		params.Argon2Threads != passwordParams.Argon2Threads ||
+		uint32(len(key)) != passwordParams.Argon2KeyLen ||
+		uint32(len(salt)) != passwordParams.Argon2SaltLen
+}
+
+// IsPasswordHash жол осы пакет таныйтын хэш екенін тексереді.
+func IsPasswordHash(value string) bool {
+	if isBcryptHash(value) {
+		_, err := bcrypt.Cost([]byte(value))
+		return err == nil
+	}
+	_, _, _, err := decodeArgon2id(value)
+	return err == nil
+}
+
+func isBcryptHash(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
+
+func decodeArgon2id(encoded string) (PasswordParams, []byte, []byte, error) {
+	var params PasswordParams
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || !validArgon2Params(params.Argon2Time, params.Argon2Memory, params.Argon2Threads, uint32(len(key))) {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.Algorithm = AlgorithmArgon2id
+	return params, salt, key, nil
+}
+
+// validArgon2Params параметрлер argon2.IDKey үшін жарамды және шектерден аспайтынын тексереді.
+// Жад кемінде әр ағынға 8 KiB болуы керек.
+func validArgon2Params(time, memory uint32, threads uint8, keyLen uint32) bool {
+	return time > 0 && time <= maxArgon2Time &&
+		threads > 0 &&
+		memory >= 8*uint32(threads) && memory <= maxArgon2Memory &&
+		keyLen >= minArgon2KeyLen && keyLen <= maxArgon2KeyLen
+}
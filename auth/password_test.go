@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testParams тесттер жылдам өтуі үшін ең аз жад пен уақыт
+func testParams(t *testing.T) {
+	t.Helper()
+	previous := passwordParams
+	params := DefaultPasswordParams
+	params.Argon2Time, params.Argon2Memory, params.Argon2Threads = 1, 64, 1
+	if err := SetPasswordParams(params); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { passwordParams = previous })
+}
+
+func TestVerifyPassword(t *testing.T) {
+	testParams(t)
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyPassword(hash, "secret"); !ok || err != nil {
+		t.Errorf("VerifyPassword(correct) = %v, %v", ok, err)
+	}
+	if ok, err := VerifyPassword(hash, "wrong"); ok || err != nil {
+		t.Errorf("VerifyPassword(wrong) = %v, %v", ok, err)
+	}
+}
+
+func TestVerifyPasswordRejectsCorruptedHash(t *testing.T) {
+	testParams(t)
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	withParams := func(params string) string {
+		corrupted := append([]string{}, parts...)
+		corrupted[3] = params
+		return strings.Join(corrupted, "$")
+	}
+	withKey := func(key string) string {
+		corrupted := append([]string{}, parts...)
+		corrupted[5] = key
+		return strings.Join(corrupted, "$")
+	}
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"zero threads", withParams("m=64,t=1,p=0")},
+		{"zero time", withParams("m=64,t=0,p=1")},
+		{"zero memory", withParams("m=0,t=1,p=1")},
+		{"memory below 8 KiB per thread", withParams("m=15,t=1,p=2")},
+		{"huge memory", withParams("m=4294967295,t=1,p=1")},
+		{"huge time", withParams("m=64,t=100000,p=1")},
+		{"threads overflow", withParams("m=64,t=1,p=256")},
+		{"negative memory", withParams("m=-1,t=1,p=1")},
+		{"short key", withKey("AAA")},
+		{"empty key", withKey("")},
+		{"empty salt", strings.Join(append(append([]string{}, parts[:4]...), "", parts[5]), "$")},
+		{"wrong version", strings.Replace(hash, "v=19", "v=16", 1)},
+		{"truncated", strings.Join(parts[:5], "$")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := VerifyPassword(tt.hash, "secret")
+			if ok || !errors.Is(err, ErrInvalidHash) {
+				t.Errorf("VerifyPassword(%q) = %v, %v, want ErrInvalidHash", tt.hash, ok, err)
+			}
+			if IsPasswordHash(tt.hash) {
+				t.Errorf("IsPasswordHash(%q) = true", tt.hash)
+			}
+		})
+	}
+}
+
+func TestSetPasswordParams(t *testing.T) {
+	testParams(t)
+	tests := []struct {
+		name   string
+		modify func(*PasswordParams)
+		ok     bool
+	}{
+		{"defaults", func(p *PasswordParams) {}, true},
+		{"bcrypt", func(p *PasswordParams) { p.Algorithm = AlgorithmBcrypt }, true},
+		{"bcrypt cost too low", func(p *PasswordParams) { p.Algorithm, p.BcryptCost = AlgorithmBcrypt, 1 }, false},
+		{"zero threads", func(p *PasswordParams) { p.Argon2Threads = 0 }, false},
+		{"memory too large", func(p *PasswordParams) { p.Argon2Memory = maxArgon2Memory + 1 }, false},
+		{"key too short", func(p *PasswordParams) { p.Argon2KeyLen = 2 }, false},
+		{"zero salt", func(p *PasswordParams) { p.Argon2SaltLen = 0 }, false},
+		{"unknown algorithm", func(p *PasswordParams) { p.Algorithm = "md5" }, false},
+	}
+	for _, tt := range tests {
+		params := DefaultPasswordParams
+		tt.modify(&params)
+		if err := SetPasswordParams(params); (err == nil) != tt.ok {
+			t.Errorf("%s: SetPasswordParams() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
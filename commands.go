@@ -0,0 +1,140 @@
+package main
+
+import (
+	"NomadShop/migrations"
+	"NomadShop/models"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"strconv"
+)
+
+// runCommand бір реттік әкімшілік командаларды орындайды
+func runCommand(db *gorm.DB, args []string) {
+	switch args[0] {
+	case "migrate":
+		runMigrate(db, args[1:])
+	case "hash-passwords":
+		updated, err := models.HashPlaintextPasswords(db)
+		if err != nil {
+			log.Fatal("Error hashing passwords:", err)
+		}
+		fmt.Printf("Hashed %d plaintext passwords\n", updated)
+	case "reconcile-stock":
+		reconcileStock(db, len(args) > 1 && args[1] == "--fix")
+	case "grant-role":
+		if len(args) != 3 {
+			log.Fatal("Usage: grant-role <username|email> <role>")
+		}
+		grantRole(db, args[1], args[2])
+	default:
+		log.Fatalf("Unknown command %q", args[0])
+	}
+}
+
+// grantRole алғашқы әкімшіні тағайындау үшін қолданылады
+func grantRole(db *gorm.DB, login, roleName string) {
+	user, err := models.GetUserByLogin(db, login)
+	if err != nil {
+		log.Fatal("User not found:", err)
+	}
+
+	role, err := models.GetRoleByName(db, roleName)
+	if err != nil {
+		role, err = models.CreateRole(db, &models.Role{Name: roleName})
+		if err != nil {
+			log.Fatal("Error creating role:", err)
+		}
+	}
+
+	if _, err := models.GetRoleByUserAndRoleID(db, user.ID, role.ID); err == nil {
+		fmt.Printf("User %s already has role %s\n", user.Username, role.Name)
+		return
+	}
+
+	if _, err := models.AddUserRole(db, &models.UserRole{UserID: user.ID, RoleID: role.ID}); err != nil {
+		log.Fatal("Error granting role:", err)
+	}
+	fmt.Printf("Granted role %s to %s\n", role.Name, user.Username)
+}
+
+func runMigrate(db *gorm.DB, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Usage: migrate up|down [steps]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		if err := migrateUp(db); err != nil {
+			log.Fatal("Error during migration:", err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal("Steps must be a positive number")
+			}
+		}
+		reverted, err := migrations.Down(db, steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("Error reverting migration:", err)
+		}
+	case "status":
+		statuses, err := migrations.GetStatus(db)
+		if err != nil {
+			log.Fatal("Error reading migration status:", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q", args[0])
+	}
+}
+
+// migrateUp миграцияларды қолданып, әдепкі рөлдерді толтырады
+func migrateUp(db *gorm.DB) error {
+	applied, err := migrations.Up(db)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	return models.SeedDefaultRoles(db)
+}
+
+// reconcileStock қорды ledger-мен салыстырады, --fix берілсе ledger бойынша түзетеді
+func reconcileStock(db *gorm.DB, fix bool) {
+	var drifts []models.StockDrift
+	var err error
+	if fix {
+		drifts, err = models.ReconcileStock(db)
+	} else {
+		drifts, err = models.FindStockDrift(db)
+	}
+	if err != nil {
+		log.Fatal("Error reconciling stock:", err)
+	}
+
+	for _, drift := range drifts {
+		fmt.Printf("product %d variant %d warehouse %d (%s): stock %d, ledger %d, drift %+d\n",
+			drift.ProductID, drift.VariantID, drift.WarehouseID, drift.Name, drift.Stock, drift.LedgerStock, drift.Stock-drift.LedgerStock)
+	}
+	switch {
+	case len(drifts) == 0:
+		fmt.Println("Stock matches the ledger")
+	case fix:
+		fmt.Printf("Corrected %d products\n", len(drifts))
+	default:
+		fmt.Printf("%d products drifted, run with --fix to correct them\n", len(drifts))
+	}
+}
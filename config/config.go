@@ -0,0 +1,414 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Config қолданбаның барлық баптаулары. Мәндер келесі ретпен жүктеледі:
+// әдепкі мәндер, NOMADSHOP_CONFIG көрсеткен TOML файлы, содан кейін орта айнымалылары.
+type Config struct {
+	Database  DatabaseConfig  `toml:"database"`
+	Server    ServerConfig    `toml:"server"`
+	Auth      AuthConfig      `toml:"auth"`
+	Inventory InventoryConfig `toml:"inventory"`
+	Storage   StorageConfig   `toml:"storage"`
+	Images    ImagesConfig    `toml:"images"`
+	Currency  CurrencyConfig  `toml:"currency"`
+	Shipping  ShippingConfig  `toml:"shipping"`
+	Tax       TaxConfig       `toml:"tax"`
+	Cart      CartConfig      `toml:"cart"`
+
+	Notifications NotificationsConfig `toml:"notifications"`
+}
+
+type DatabaseConfig struct {
+	DSN             string   `toml:"dsn"`
+	MaxOpenConns    int      `toml:"max_open_conns"`
+	MaxIdleConns    int      `toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `toml:"conn_max_lifetime"`
+}
+
+type ServerConfig struct {
+	Address     string   `toml:"address"`
+	GinMode     string   `toml:"gin_mode"`
+	CORSOrigins []string `toml:"cors_origins"`
+}
+
+type AuthConfig struct {
+	JWTSecret         string   `toml:"jwt_secret"`
+	AccessTokenTTL    Duration `toml:"access_token_ttl"`
+	RefreshTokenTTL   Duration `toml:"refresh_token_ttl"`
+	PasswordAlgorithm string   `toml:"password_algorithm"`
+	BcryptCost        int      `toml:"bcrypt_cost"`
+}
+
+type InventoryConfig struct {
+	ReservationTTL Duration `toml:"reservation_ttl"`
+	SweepInterval  Duration `toml:"sweep_interval"`
+}
+
+// StorageConfig жүктелген файлдар қоймасы. Әзірге тек "local" драйвері бар.
+type StorageConfig struct {
+	Driver   string `toml:"driver"`
+	LocalDir string `toml:"local_dir"`
+	BaseURL  string `toml:"base_url"`
+}
+
+type ImagesConfig struct {
+	MaxUploadSize   int      `toml:"max_upload_size"` // байт
+	MaxPixels       int      `toml:"max_pixels"`      // ені × биіктігі
+	ProcessInterval Duration `toml:"process_interval"`
+}
+
+// CurrencyConfig ақша баптаулары. Default дүкеннің негізгі валютасы (ISO 4217 коды),
+// тауар бағалары осы валютада сақталады. Rounding бағаларды басқа валютаға түрлендіргенде
+// қолданылады (half_up, half_even, up, down), ал RoundingSteps валюта бойынша дөңгелектеу
+// қадамын ең кіші бірлікпен береді, мысалы RUB = 100 бүтін рубльге дейін дөңгелектейді.
+type CurrencyConfig struct {
+	Default       string           `toml:"default"`
+	Rounding      string           `toml:"rounding"`
+	RoundingSteps map[string]int64 `toml:"rounding_steps"`
+}
+
+// ShippingConfig жеткізу құны. FlatRate негізгі валютадағы ондық сома, мысалы "1500.00";
+// "0" болса, жеткізу тегін.
+type ShippingConfig struct {
+	FlatRate string `toml:"flat_rate"`
+}
+
+// TaxConfig салық (ҚҚС) баптаулары. Rate пайызбен, ең көбі екі ондық таңбамен, мысалы "12";
+// "0" болса, салық есептелмейді. Included болса, салық бағаға кірген деп саналады және
+// тек көрсетіледі, әйтпесе жеңілдіктен кейінгі тауарлар сомасына қосылады.
+type TaxConfig struct {
+	Rate     string `toml:"rate"`
+	Included bool   `toml:"included"`
+}
+
+// CartConfig себет баптаулары. GuestTTL соңғы қолданыстан кейін қонақ себеті қанша уақыт
+// сақталады. AbandonedAfter бойы өзгермеген себет тасталған деп саналып, пайдаланушыға
+// еске салу жіберіледі; ReminderInterval тасталған себеттерді іздеу жиілігі.
+type CartConfig struct {
+	GuestTTL         Duration `toml:"guest_ttl"`
+	SweepInterval    Duration `toml:"sweep_interval"`
+	AbandonedAfter   Duration `toml:"abandoned_after"`
+	ReminderInterval Duration `toml:"reminder_interval"`
+}
+
+// NotificationsConfig хабарламалар. Әзірге "log" (журналға жазу) және "file"
+// (FilePath файлына JSON жолдары) драйверлері бар.
+type NotificationsConfig struct {
+	Driver   string `toml:"driver"`
+	FilePath string `toml:"file_path"`
+}
+
+func (c CurrencyConfig) RoundingRule() money.Rounding {
+	return money.Rounding{Mode: c.Rounding, Steps: c.RoundingSteps}
+}
+
+// ShippingRate жеткізу құны негізгі валютада. Мән Validate арқылы тексерілген.
+func (c *Config) ShippingRate() money.Money {
+	rate, _ := money.Parse(c.Shipping.FlatRate, c.Currency.Default)
+	return rate
+}
+
+// TaxRate салық мөлшерлемесі базистік пунктпен (1200 = 12%). Мән Validate арқылы тексерілген.
+func (c *Config) TaxRate() int64 {
+	rate, _ := parseBasisPoints(c.Tax.Rate)
+	return rate
+}
+
+// Duration TOML файлында "15m", "720h" түрінде жазылады
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func Default() Config {
+	return Config{
+		Database: DatabaseConfig{
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration{30 * time.Minute},
+		},
+		Server: ServerConfig{
+			Address: ":8080",
+			GinMode: "debug",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:    Duration{15 * time.Minute},
+			RefreshTokenTTL:   Duration{30 * 24 * time.Hour},
+			PasswordAlgorithm: "argon2id",
+			BcryptCost:        12,
+		},
+		Inventory: InventoryConfig{
+			ReservationTTL: Duration{30 * time.Minute},
+			SweepInterval:  Duration{time.Minute},
+		},
+		Storage: StorageConfig{
+			Driver:   "local",
+			LocalDir: "uploads",
+			BaseURL:  "/uploads",
+		},
+		Images: ImagesConfig{
+			MaxUploadSize:   10 << 20,
+			MaxPixels:       40_000_000,
+			ProcessInterval: Duration{5 * time.Second},
+		},
+		Currency: CurrencyConfig{
+			Default:  "KZT",
+			Rounding: "half_up",
+		},
+		Shipping: ShippingConfig{
+			FlatRate: "0",
+		},
+		Tax: TaxConfig{
+			Rate: "0",
+		},
+		Cart: CartConfig{
+			GuestTTL:         Duration{30 * 24 * time.Hour},
+			SweepInterval:    Duration{time.Hour},
+			AbandonedAfter:   Duration{24 * time.Hour},
+			ReminderInterval: Duration{15 * time.Minute},
+		},
+		Notifications: NotificationsConfig{
+			Driver:   "log",
+			FilePath: "notifications.log",
+		},
+	}
+}
+
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("NOMADSHOP_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+		if err := toml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	}
+
+	if err := errors.Join(applyEnv(&cfg), cfg.Validate()); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func applyEnv(cfg *Config) error {
+	var errs []error
+
+	setString(&cfg.Database.DSN, "NOMADSHOP_DB_DSN")
+	errs = append(errs, setInt(&cfg.Database.MaxOpenConns, "NOMADSHOP_DB_MAX_OPEN_CONNS"))
+	errs = append(errs, setInt(&cfg.Database.MaxIdleConns, "NOMADSHOP_DB_MAX_IDLE_CONNS"))
+	errs = append(errs, setDuration(&cfg.Database.ConnMaxLifetime, "NOMADSHOP_DB_CONN_MAX_LIFETIME"))
+
+	setString(&cfg.Server.Address, "NOMADSHOP_LISTEN_ADDR")
+	setString(&cfg.Server.GinMode, "NOMADSHOP_GIN_MODE")
+	if value, ok := os.LookupEnv("NOMADSHOP_CORS_ORIGINS"); ok {
+		cfg.Server.CORSOrigins = splitList(value)
+	}
+
+	setString(&cfg.Auth.JWTSecret, "NOMADSHOP_JWT_SECRET")
+	errs = append(errs, setDuration(&cfg.Auth.AccessTokenTTL, "NOMADSHOP_ACCESS_TOKEN_TTL"))
+	errs = append(errs, setDuration(&cfg.Auth.RefreshTokenTTL, "NOMADSHOP_REFRESH_TOKEN_TTL"))
+	setString(&cfg.Auth.PasswordAlgorithm, "NOMADSHOP_PASSWORD_ALGORITHM")
+	errs = append(errs, setInt(&cfg.Auth.BcryptCost, "NOMADSHOP_BCRYPT_COST"))
+
+	errs = append(errs, setDuration(&cfg.Inventory.ReservationTTL, "NOMADSHOP_RESERVATION_TTL"))
+	errs = append(errs, setDuration(&cfg.Inventory.SweepInterval, "NOMADSHOP_RESERVATION_SWEEP_INTERVAL"))
+
+	setString(&cfg.Storage.Driver, "NOMADSHOP_STORAGE_DRIVER")
+	setString(&cfg.Storage.LocalDir, "NOMADSHOP_STORAGE_LOCAL_DIR")
+	setString(&cfg.Storage.BaseURL, "NOMADSHOP_STORAGE_BASE_URL")
+
+	errs = append(errs, setInt(&cfg.Images.MaxUploadSize, "NOMADSHOP_IMAGE_MAX_UPLOAD_SIZE"))
+	errs = append(errs, setInt(&cfg.Images.MaxPixels, "NOMADSHOP_IMAGE_MAX_PIXELS"))
+	errs = append(errs, setDuration(&cfg.Images.ProcessInterval, "NOMADSHOP_IMAGE_PROCESS_INTERVAL"))
+
+	setString(&cfg.Currency.Default, "NOMADSHOP_CURRENCY")
+	setString(&cfg.Currency.Rounding, "NOMADSHOP_CURRENCY_ROUNDING")
+
+	setString(&cfg.Shipping.FlatRate, "NOMADSHOP_SHIPPING_FLAT_RATE")
+
+	setString(&cfg.Tax.Rate, "NOMADSHOP_TAX_RATE")
+	errs = append(errs, setBool(&cfg.Tax.Included, "NOMADSHOP_TAX_INCLUDED"))
+
+	errs = append(errs, setDuration(&cfg.Cart.GuestTTL, "NOMADSHOP_GUEST_CART_TTL"))
+	errs = append(errs, setDuration(&cfg.Cart.SweepInterval, "NOMADSHOP_CART_SWEEP_INTERVAL"))
+	errs = append(errs, setDuration(&cfg.Cart.AbandonedAfter, "NOMADSHOP_CART_ABANDONED_AFTER"))
+	errs = append(errs, setDuration(&cfg.Cart.ReminderInterval, "NOMADSHOP_CART_REMINDER_INTERVAL"))
+
+	setString(&cfg.Notifications.Driver, "NOMADSHOP_NOTIFICATIONS_DRIVER")
+	setString(&cfg.Notifications.FilePath, "NOMADSHOP_NOTIFICATIONS_FILE")
+
+	return errors.Join(errs...)
+}
+
+// Validate барлық қателерді бірден қайтарады
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("config: database dsn is required (NOMADSHOP_DB_DSN)"))
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		errs = append(errs, errors.New("config: database max_open_conns must be positive"))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("config: database max_idle_conns must be between 0 and max_open_conns"))
+	}
+
+	if c.Server.Address == "" {
+		errs = append(errs, errors.New("config: server address is required (NOMADSHOP_LISTEN_ADDR)"))
+	}
+	switch c.Server.GinMode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("config: gin_mode must be debug, release or test, got %q", c.Server.GinMode))
+	}
+
+	if len(c.Auth.JWTSecret) < 32 {
+		errs = append(errs, errors.New("config: jwt_secret must be at least 32 characters (NOMADSHOP_JWT_SECRET)"))
+	}
+	if c.Auth.AccessTokenTTL.Duration <= 0 || c.Auth.RefreshTokenTTL.Duration <= 0 {
+		errs = append(errs, errors.New("config: token ttl values must be positive"))
+	}
+	if c.Auth.AccessTokenTTL.Duration >= c.Auth.RefreshTokenTTL.Duration {
+		errs = append(errs, errors.New("config: access_token_ttl must be shorter than refresh_token_ttl"))
+	}
+	if c.Auth.PasswordAlgorithm != "argon2id" && c.Auth.PasswordAlgorithm != "bcrypt" {
+		errs = append(errs, fmt.Errorf("config: password_algorithm must be argon2id or bcrypt, got %q", c.Auth.PasswordAlgorithm))
+	}
+
+	if c.Inventory.ReservationTTL.Duration <= 0 || c.Inventory.SweepInterval.Duration <= 0 {
+		errs = append(errs, errors.New("config: inventory reservation_ttl and sweep_interval must be positive"))
+	}
+
+	if c.Storage.Driver != "local" {
+		errs = append(errs, fmt.Errorf("config: storage driver must be local, got %q", c.Storage.Driver))
+	}
+	if c.Storage.Driver == "local" && c.Storage.LocalDir == "" {
+		errs = append(errs, errors.New("config: storage local_dir is required (NOMADSHOP_STORAGE_LOCAL_DIR)"))
+	}
+	if c.Images.MaxUploadSize <= 0 || c.Images.MaxPixels <= 0 || c.Images.ProcessInterval.Duration <= 0 {
+		errs = append(errs, errors.New("config: images max_upload_size, max_pixels and process_interval must be positive"))
+	}
+
+	if _, err := money.Exponent(c.Currency.Default); err != nil {
+		errs = append(errs, fmt.Errorf("config: currency default must be a supported ISO 4217 code, got %q", c.Currency.Default))
+	}
+	if err := c.Currency.RoundingRule().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("config: currency rounding: %w", err))
+	}
+	if rate, err := money.Parse(c.Shipping.FlatRate, c.Currency.Default); err != nil || rate.IsNegative() {
+		errs = append(errs, fmt.Errorf("config: shipping flat_rate must be a non-negative amount in %s, got %q", c.Currency.Default, c.Shipping.FlatRate))
+	}
+	if rate, err := parseBasisPoints(c.Tax.Rate); err != nil || rate >= 10000 {
+		errs = append(errs, fmt.Errorf("config: tax rate must be a percentage between 0 and 100 with at most two decimals, got %q", c.Tax.Rate))
+	}
+	if c.Cart.GuestTTL.Duration <= 0 || c.Cart.SweepInterval.Duration <= 0 {
+		errs = append(errs, errors.New("config: cart guest_ttl and sweep_interval must be positive"))
+	}
+	if c.Cart.AbandonedAfter.Duration <= 0 || c.Cart.ReminderInterval.Duration <= 0 {
+		errs = append(errs, errors.New("config: cart abandoned_after and reminder_interval must be positive"))
+	}
+
+	switch c.Notifications.Driver {
+	case "log":
+	case "file":
+		if c.Notifications.FilePath == "" {
+			errs = append(errs, errors.New("config: notifications file_path is required (NOMADSHOP_NOTIFICATIONS_FILE)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("config: notifications driver must be log or file, got %q", c.Notifications.Driver))
+	}
+
+	return errors.Join(errs...)
+}
+
+func setString(target *string, key string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*target = value
+	}
+}
+
+func setInt(target *int, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("config: %s must be an integer, got %q", key, value)
+	}
+	*target = parsed
+	return nil
+}
+
+func setBool(target *bool, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("config: %s must be true or false, got %q", key, value)
+	}
+	*target = parsed
+	return nil
+}
+
+func setDuration(target *Duration, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("config: %s must be a duration like 15m, got %q", key, value)
+	}
+	target.Duration = parsed
+	return nil
+}
+
+// parseBasisPoints "12.5" түріндегі пайызды базистік пунктке (1250) айналдырады
+func parseBasisPoints(percent string) (int64, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(percent))
+	if !ok || rate.Sign() < 0 || strings.ContainsAny(percent, "/eE") {
+		return 0, fmt.Errorf("invalid percentage %q", percent)
+	}
+	points := rate.Mul(rate, big.NewRat(100, 1))
+	if !points.IsInt() || !points.Num().IsInt64() {
+		return 0, fmt.Errorf("percentage %q has more than two decimals", percent)
+	}
+	return points.Num().Int64(), nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"NomadShop/listing"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AbandonedCartHandler struct {
+	DB *gorm.DB
+}
+
+func NewAbandonedCartHandler(db *gorm.DB) *AbandonedCartHandler {
+	return &AbandonedCartHandler{DB: db}
+}
+
+var abandonedCartListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":               {Column: "id", Kind: listing.Int},
+		"created_at":       {Column: "created_at", Kind: listing.Time},
+		"last_activity_at": {Column: "last_activity_at", Kind: listing.Time},
+		"subtotal":         {Column: "subtotal_amount", Kind: listing.Int},
+	},
+	DefaultSort: "-created_at",
+	Filters: []listing.Filter{
+		{Param: "user_id", Column: "user_id", Kind: listing.Int},
+		{Param: "status", Column: "status", Kind: listing.String, Op: listing.In},
+		{Param: "reason", Column: "reason", Kind: listing.String, Op: listing.In},
+		{Param: "date_from", Column: "created_at", Kind: listing.Time, Op: listing.Gte},
+		{Param: "date_to", Column: "created_at", Kind: listing.Time, Op: listing.Lte},
+	},
+}
+
+// GetAbandonedCarts тасталған себеттер мен еске салулардың тізімі (маркетинг есептері үшін)
+func (h *AbandonedCartHandler) GetAbandonedCarts(c *gin.Context) {
+	query, ok := listQuery(c, abandonedCartListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.AbandonedCart](h.DB, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get abandoned carts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
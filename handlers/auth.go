@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"NomadShop/auth"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuthHandler struct {
+	DB       *gorm.DB
+	Tokens   *auth.TokenIssuer
+	Settings models.Settings
+}
+
+func NewAuthHandler(db *gorm.DB, tokens *auth.TokenIssuer, settings models.Settings) *AuthHandler {
+	return &AuthHandler{DB: db, Tokens: tokens, Settings: settings}
+}
+
+type loginRequest struct {
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var input loginRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	user, err := models.GetUserByLogin(h.DB, input.Login)
+	if err != nil {
+		// Пайдаланушы жоқ болса да пароль тексерілетіндей уақыт жұмсаймыз
+		auth.VerifyDummyPassword(input.Password)
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid credentials"})
+		return
+	}
+
+	ok, err := models.CheckUserPassword(h.DB, user, input.Password)
+	if err != nil {
+		log.Printf("Error checking password for user %d: %v", user.ID, err)
+	}
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid credentials"})
+		return
+	}
+
+	// Жаңа сессия үшін жаңа токендер отбасы
+	familyID, err := auth.NewTokenFamily()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating session"})
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating session"})
+		return
+	}
+
+	if _, err := models.CreateRefreshToken(h.DB, user.ID, familyID, refreshHash, time.Now().Add(h.Tokens.RefreshTTL())); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating session"})
+		return
+	}
+
+	h.respondWithTokens(c, user.ID, refreshToken, h.mergeGuestCart(c, user.ID))
+}
+
+// mergeGuestCart кіру кезінде қонақ себетін пайдаланушы себетіне біріктіреді. Біріктіру
+// сәтсіз болса, кіру тоқтамайды: токен сақталады және келесі кіруде қайта байқап көріледі.
+func (h *AuthHandler) mergeGuestCart(c *gin.Context, userID uint) *models.CartMerge {
+	token := cartToken(c)
+	if token == "" {
+		return nil
+	}
+
+	merge, err := models.MergeGuestCart(h.DB, h.Settings, auth.HashCartToken(token), userID)
+	if err != nil && !errors.Is(err, models.ErrGuestCartNotFound) {
+		log.Printf("Error merging guest cart for user %d: %v", userID, err)
+		return nil
+	}
+	clearCartToken(c)
+	return merge
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var input refreshRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error refreshing session"})
+		return
+	}
+
+	rotated, err := models.RotateRefreshToken(h.DB, auth.HashRefreshToken(input.RefreshToken), refreshHash, time.Now().Add(h.Tokens.RefreshTTL()))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRefreshTokenReused):
+			log.Printf("Refresh token reuse detected, session revoked")
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh token has already been used"})
+		case errors.Is(err, models.ErrRefreshTokenInvalid):
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error refreshing session"})
+		}
+		return
+	}
+
+	h.respondWithTokens(c, rotated.UserID, refreshToken, nil)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var input refreshRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if err := models.RevokeRefreshToken(h.DB, auth.HashRefreshToken(input.RefreshToken)); err != nil {
+		if errors.Is(err, models.ErrRefreshTokenInvalid) {
+			c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error logging out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+func (h *AuthHandler) respondWithTokens(c *gin.Context, userID uint, refreshToken string, cartMerge *models.CartMerge) {
+	accessToken, err := h.Tokens.IssueAccessToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error issuing access token"})
+		return
+	}
+
+	response := gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(h.Tokens.AccessTTL().Seconds()),
+	}
+	if cartMerge != nil {
+		response["cart_merge"] = cartMerge
+	}
+	c.JSON(http.StatusOK, response)
+}
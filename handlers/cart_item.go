@@ -4,76 +4,121 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"NomadShop/listing"
 	"NomadShop/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type CartItemHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Settings models.Settings
 }
 
-func NewCartItemHandler(db *gorm.DB) *CartItemHandler {
-	return &CartItemHandler{DB: db}
+func NewCartItemHandler(db *gorm.DB, settings models.Settings) *CartItemHandler {
+	return &CartItemHandler{DB: db, Settings: settings}
 }
 
-func (ch *CartItemHandler) GetAllCartItems(c *gin.Context) {
-	var cartItems []models.CartItem
+var cartItemListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":       {Column: "id", Kind: listing.Int},
+		"quantity": {Column: "quantity", Kind: listing.Int},
+	},
+	Filters: []listing.Filter{
+		{Param: "user_id", Column: "user_id", Kind: listing.Int},
+		{Param: "variant_id", Column: "variant_id", Kind: listing.Int, Op: listing.In},
+	},
+}
 
-	err := ch.DB.Preload("Product").Preload("Product.Category").Find(&cartItems).Error
+func (ch *CartItemHandler) GetAllCartItems(c *gin.Context) {
+	query, ok := listQuery(c, cartItemListing)
+	if !ok {
+		return
+	}
 
+	page, err := listing.Find[models.CartItem](ch.DB, query, preload("Product", "Product.Category", "Variant"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching all cart items"})
 		return
 	}
 
-	for _, cartItem := range cartItems {
-		fmt.Printf("CartItem: %v\n", cartItem)
-	}
-
-	c.JSON(http.StatusOK, cartItems)
+	respondInCurrency(c, ch.DB, ch.Settings, page)
 }
 
 func (ch *CartItemHandler) GetCartItems(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+	userID, ok := targetUserID(c, ch.DB, c.Param("user_id"), models.PermUserRead)
+	if !ok {
 		return
 	}
 
-	cartItems, err := models.GetCartItems(ch.DB, uint(userID))
+	cartItems, err := models.GetCartItems(ch.DB, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching cart items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, cartItems)
+	respondInCurrency(c, ch.DB, ch.Settings, cartItems)
 }
 
 func (ch *CartItemHandler) GetCartItemsByUser(c *gin.Context) {
-	userIDStr := c.DefaultQuery("user_id", "")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User ID is required"})
+	// user_id берілмесе, ағымдағы пайдаланушының себеті қайтарылады
+	userID, ok := targetUserID(c, ch.DB, c.Query("user_id"), models.PermUserRead)
+	if !ok {
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
+	var cartItems []models.CartItem
+	// Продукция мен оның категориясын алдын ала жүктеу
+	err := ch.DB.Preload("Product").Preload("Product.Category").Preload("Variant").Where("user_id = ?", userID).Find(&cartItems).Error
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching cart items"})
 		return
 	}
 
-	var cartItems []models.CartItem
-	// Продукция мен оның категориясын алдын ала жүктеу
-	err = ch.DB.Preload("Product").Preload("Product.Category").Where("user_id = ?", userID).Find(&cartItems).Error
+	respondInCurrency(c, ch.DB, ch.Settings, cartItems)
+}
+
+// GetCartSummary себеттің Checkout алатын бағамен толық есебін қайтарады: жолдар, жеңілдіктер,
+// салық, жеткізу және жолдар бойынша ескертулер. ?currency және ?coupon_code қосымша.
+func (ch *CartItemHandler) GetCartSummary(c *gin.Context) {
+	userID, ok := targetUserID(c, ch.DB, c.Query("user_id"), models.PermUserRead)
+	if !ok {
+		return
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	summary, err := models.SummarizeCart(ch.DB, ch.Settings, userID, currency, c.Query("coupon_code"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching cart items"})
+		respondQuoteError(c, err, currency, "Failed to get cart summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// SetCartReminders {"enabled": false} тасталған себет туралы еске салулардан бас тартады
+func (ch *CartItemHandler) SetCartReminders(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if err := models.SetCartReminders(ch.DB, userID, *input.Enabled); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error updating cart reminders"})
 		return
 	}
 
-	c.JSON(http.StatusOK, cartItems)
+	c.JSON(http.StatusOK, gin.H{"message": "Cart reminder preference saved", "enabled": *input.Enabled})
 }
 
 func (ch *CartItemHandler) GetCartItemsByProduct(c *gin.Context) {
@@ -91,24 +136,34 @@ func (ch *CartItemHandler) GetCartItemsByProduct(c *gin.Context) {
 		return
 	}
 
+	query, ok := listQuery(c, cartItemListing)
+	if !ok {
+		return
+	}
+
 	// Preload арқылы өнім мен оның категориясын жүктейміз
-	var cartItems []models.CartItem
-	err = ch.DB.Preload("Product").Preload("Product.Category").Where("product_id = ?", productID).Find(&cartItems).Error
+	page, err := listing.Find[models.CartItem](ch.DB.Where("product_id = ?", productID), query, preload("Product", "Product.Category", "Variant"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching cart items"})
 		return
 	}
 
 	// Себеттегі өнімдермен бірге өнімнің толық мәліметтері қайтарылады
-	c.JSON(http.StatusOK, cartItems)
+	respondInCurrency(c, ch.DB, ch.Settings, page)
 }
 
 func (ch *CartItemHandler) CreateCartItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	var cartItem models.CartItem
 	if err := c.ShouldBindJSON(&cartItem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
+	cartItem.UserID = userID
 
 	fmt.Printf("Received ProductID: %d\n", cartItem.ProductID)
 
@@ -119,13 +174,24 @@ func (ch *CartItemHandler) CreateCartItem(c *gin.Context) {
 		return
 	}
 
-	if cartItem.Quantity > uint(product.Stock) {
+	// VariantID берілмесе, өнімнің әдепкі нұсқасы алынады
+	variant, err := models.ResolveVariant(ch.DB, product.ID, cartItem.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+		return
+	}
+	cartItem.VariantID = variant.ID
+	// Баға өзгерсе, себеттің есебінде ескерту көрсетіледі
+	addedPrice := variant.UnitPrice(product.Price)
+	cartItem.AddedPrice = &addedPrice
+
+	if cartItem.Quantity > variant.Stock {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Not enough stock"})
 		return
 	}
 
 	var existingCartItem models.CartItem
-	if err := ch.DB.Where("user_id = ? AND product_id = ?", cartItem.UserID, cartItem.ProductID).First(&existingCartItem).Error; err == nil {
+	if err := ch.DB.Where("user_id = ? AND variant_id = ?", cartItem.UserID, cartItem.VariantID).First(&existingCartItem).Error; err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Product already in cart"})
 		return
 	}
@@ -159,8 +225,14 @@ func (ch *CartItemHandler) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
+	// Басқа пайдаланушының жазбасы бар екенін ашпау үшін 404 қайтарылады
+	if allowed, err := canAccess(c, ch.DB, cartItem.UserID, models.PermUserManage); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Cart item not found"})
+		return
+	}
+
 	// Жаңарту: тек санды өзгерту
-	if updatedCartItem.Quantity > uint(cartItem.Product.Stock) {
+	if updatedCartItem.Quantity > cartItem.Variant.Stock {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Not enough stock to update quantity"})
 		return
 	}
@@ -182,6 +254,17 @@ func (ch *CartItemHandler) DeleteCartItem(c *gin.Context) {
 		return
 	}
 
+	cartItem, err := models.GetCartItemByID(ch.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Cart item not found"})
+		return
+	}
+
+	if allowed, err := canAccess(c, ch.DB, cartItem.UserID, models.PermUserManage); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Cart item not found"})
+		return
+	}
+
 	// Өнімді табу және өшіру
 	if err := ch.DB.Where("id = ?", id).Delete(&models.CartItem{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error deleting cart item"})
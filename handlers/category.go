@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"NomadShop/listing"
 	"NomadShop/models"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
@@ -17,13 +19,30 @@ func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
 }
 
 
+var categoryListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":   {Column: "id", Kind: listing.Int},
+		"name": {Column: "name", Kind: listing.String},
+	},
+	Filters: []listing.Filter{
+		{Param: "parent_id", Column: "parent_id", Kind: listing.Int, Op: listing.Eq},
+		// root=true тек түбірдегі санаттарды, root=false тек ішкі санаттарды қайтарады
+		{Param: "root", Column: "parent_id", Kind: listing.Bool, Where: "(parent_id IS NULL) = ?"},
+	},
+}
+
 func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
-	categories, err := models.GetAllCategories(h.DB)
+	query, ok := listQuery(c, categoryListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Category](h.DB, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get categories"})
 		return
 	}
-	c.JSON(http.StatusOK, categories)
+	c.JSON(http.StatusOK, page)
 }
 
 
@@ -35,7 +54,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 	}
 
 	if _, err := models.CreateCategory(h.DB, &category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create category"})
+		respondCategoryError(c, err, "Failed to create category")
 		return
 	}
 
@@ -59,3 +78,109 @@ func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, category)
 }
+
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid category ID"})
+		return
+	}
+
+	var input models.Category
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	category, err := models.UpdateCategory(h.DB, uint(id), &input)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to update category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// MoveCategory санатты ішкі санаттарымен бірге басқа ата-анаға көшіреді.
+// parent_id null болса, санат түбірге шығады.
+func (h *CategoryHandler) MoveCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid category ID"})
+		return
+	}
+
+	var input struct {
+		ParentID *uint `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	category, err := models.MoveCategory(h.DB, uint(id), input.ParentID)
+	if err != nil {
+		respondCategoryError(c, err, "Failed to move category")
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid category ID"})
+		return
+	}
+
+	if err := models.DeleteCategory(h.DB, uint(id)); err != nil {
+		respondCategoryError(c, err, "Failed to delete category")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	tree, err := models.GetCategoryTree(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+func (h *CategoryHandler) GetCategoryBreadcrumbs(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid category ID"})
+		return
+	}
+
+	breadcrumbs, err := models.GetCategoryBreadcrumbs(h.DB, uint(id))
+	if err != nil {
+		respondCategoryError(c, err, "Failed to get breadcrumbs")
+		return
+	}
+
+	c.JSON(http.StatusOK, breadcrumbs)
+}
+
+func respondCategoryError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Category not found"})
+	case errors.Is(err, models.ErrInvalidSlug):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Slug may contain only latin letters, digits and dashes"})
+	case errors.Is(err, models.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"message": "Category with this slug already exists"})
+	case errors.Is(err, models.ErrCategoryCycle):
+		c.JSON(http.StatusConflict, gin.H{"message": "Category cannot be moved into its own subcategory"})
+	case errors.Is(err, models.ErrCategoryNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"message": "Category has subcategories or products and cannot be deleted"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"NomadShop/listing"
+	"NomadShop/models"
+	"NomadShop/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CouponHandler struct {
+	DB       *gorm.DB
+	Settings models.Settings
+}
+
+func NewCouponHandler(db *gorm.DB, settings models.Settings) *CouponHandler {
+	return &CouponHandler{DB: db, Settings: settings}
+}
+
+var couponListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":         {Column: "id", Kind: listing.Int},
+		"code":       {Column: "code", Kind: listing.String},
+		"created_at": {Column: "created_at", Kind: listing.Time},
+	},
+	DefaultSort: "-created_at",
+	Filters: []listing.Filter{
+		{Param: "code", Column: "code", Kind: listing.String, Op: listing.In},
+		{Param: "type", Column: "type", Kind: listing.String, Op: listing.In},
+		{Param: "active", Column: "active", Kind: listing.Bool},
+	},
+}
+
+var redemptionListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":         {Column: "id", Kind: listing.Int},
+		"created_at": {Column: "created_at", Kind: listing.Time},
+	},
+	DefaultSort: "-created_at",
+	Filters: []listing.Filter{
+		{Param: "user_id", Column: "user_id", Kind: listing.Int},
+		{Param: "date_from", Column: "created_at", Kind: listing.Time, Op: listing.Gte},
+		{Param: "date_to", Column: "created_at", Kind: listing.Time, Op: listing.Lte},
+	},
+}
+
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	query, ok := listQuery(c, couponListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Coupon](h.DB, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get coupons"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *CouponHandler) GetCouponByID(c *gin.Context) {
+	id, ok := couponID(c)
+	if !ok {
+		return
+	}
+
+	coupon, err := models.GetCouponByID(h.DB, id)
+	if err != nil {
+		respondCouponAdminError(c, err, "Failed to get coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var coupon models.Coupon
+	if err := c.ShouldBindJSON(&coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	created, err := models.CreateCoupon(h.DB, h.Settings, &coupon)
+	if err != nil {
+		respondCouponAdminError(c, err, "Failed to create coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, ok := couponID(c)
+	if !ok {
+		return
+	}
+
+	var coupon models.Coupon
+	if err := c.ShouldBindJSON(&coupon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	updated, err := models.UpdateCoupon(h.DB, h.Settings, id, &coupon)
+	if err != nil {
+		respondCouponAdminError(c, err, "Failed to update coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, ok := couponID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteCoupon(h.DB, id); err != nil {
+		respondCouponAdminError(c, err, "Failed to delete coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon deleted successfully"})
+}
+
+// GetRedemptions купонның қай тапсырыстарда қолданылғанын көрсетеді (есептер үшін)
+func (h *CouponHandler) GetRedemptions(c *gin.Context) {
+	id, ok := couponID(c)
+	if !ok {
+		return
+	}
+	query, ok := listQuery(c, redemptionListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.CouponRedemption](h.DB.Where("coupon_id = ?", id), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get coupon redemptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ApplyToCart купонды ағымдағы пайдаланушының себетіне қолданып, бағаның толық есебін қайтарады.
+// Себет өзгермейді: купон коды рәсімдеу кезінде coupon_code ретінде қайта жіберіледі.
+func (h *CouponHandler) ApplyToCart(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Coupon code is required"})
+		return
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	breakdown, err := models.QuoteCart(h.DB, h.Settings, userID, currency, input.Code)
+	if err != nil {
+		respondQuoteError(c, err, currency, "Failed to apply coupon")
+		return
+	}
+
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// respondQuoteError себетті бағалау қателеріне жауап жазады
+func respondQuoteError(c *gin.Context, err error, currency, fallback string) {
+	if respondCouponError(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, models.ErrCartEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Cart is empty"})
+	case errors.Is(err, models.ErrProductUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"message": "Some products in the cart are no longer available"})
+	case errors.Is(err, money.ErrUnknownCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown currency " + currency})
+	case errors.Is(err, money.ErrNoRate):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "No exchange rate for " + currency})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
+
+// respondCouponError купонды қолдану қателеріне жауап жазады. Қате купонға қатысты болмаса, false.
+func respondCouponError(c *gin.Context, err error) bool {
+	var couponErr *models.CouponError
+	switch {
+	case errors.Is(err, models.ErrCouponNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Coupon not found"})
+	case errors.As(err, &couponErr):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Coupon cannot be applied", "reason": couponErr.Reason})
+	default:
+		return false
+	}
+	return true
+}
+
+func respondCouponAdminError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrCouponNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Coupon not found"})
+	case errors.Is(err, models.ErrCouponCodeTaken):
+		c.JSON(http.StatusConflict, gin.H{"message": "Coupon code already exists"})
+	case errors.Is(err, models.ErrCouponInUse):
+		c.JSON(http.StatusConflict, gin.H{"message": "Coupon has been used; deactivate it instead"})
+	case errors.Is(err, models.ErrCouponInvalid), errors.Is(err, money.ErrCurrencyMismatch), errors.Is(err, money.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
+
+func couponID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid coupon ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"NomadShop/models"
+	"NomadShop/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// respondInCurrency body-ді 200 жауабы ретінде жазады. ?currency=USD берілсе, негізгі валютадағы
+// барлық сомалар сол валютаға түрлендіріледі. Басқа валютада сақталған сомалар, мысалы
+// тапсырыстың төленген жиынтығы, өзгермейді.
+func respondInCurrency(c *gin.Context, db *gorm.DB, settings models.Settings, body interface{}) {
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" || currency == settings.Currency {
+		c.JSON(http.StatusOK, body)
+		return
+	}
+	if _, err := money.Exponent(currency); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown currency " + currency})
+		return
+	}
+
+	converter, err := models.LoadConverter(db, settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to load exchange rates"})
+		return
+	}
+	if err := converter.ConvertAll(&body, settings.Currency, currency); err != nil {
+		if errors.Is(err, money.ErrNoRate) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "No exchange rate for " + currency})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to convert prices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"NomadShop/models"
+	"NomadShop/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxRateFileSize CSV файлының шегі: бағамдар саны валюталар санынан аспайды
+const maxRateFileSize = 1 << 20
+
+type ExchangeRateHandler struct {
+	DB       *gorm.DB
+	Settings models.Settings
+}
+
+func NewExchangeRateHandler(db *gorm.DB, settings models.Settings) *ExchangeRateHandler {
+	return &ExchangeRateHandler{DB: db, Settings: settings}
+}
+
+func (h *ExchangeRateHandler) GetExchangeRates(c *gin.Context) {
+	rates, err := models.GetExchangeRates(h.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get exchange rates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"base": h.Settings.Currency, "rates": rates})
+}
+
+// SetExchangeRate {"rate": "487.50"} — бір бірлік валюта негізгі валютаның қанша бірлігіне тең
+func (h *ExchangeRateHandler) SetExchangeRate(c *gin.Context) {
+	var input struct {
+		Rate string `json:"rate" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	rate, err := models.SetExchangeRate(h.DB, h.Settings, c.Param("currency"), input.Rate)
+	if err != nil {
+		respondExchangeRateError(c, err, "Failed to save exchange rate")
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+func (h *ExchangeRateHandler) DeleteExchangeRate(c *gin.Context) {
+	if err := models.DeleteExchangeRate(h.DB, c.Param("currency")); err != nil {
+		if errors.Is(err, models.ErrExchangeRateNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Exchange rate not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete exchange rate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
+
+// ImportExchangeRates "currency,rate" CSV файлын multipart "file" өрісінен немесе сұрау денесінен жүктейді.
+// Файлдағы бір жол қате болса, ешбір бағам өзгермейді.
+func (h *ExchangeRateHandler) ImportExchangeRates(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRateFileSize)
+
+	var file io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		upload, _, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "CSV file is required"})
+			return
+		}
+		defer upload.Close()
+		file = upload
+	}
+
+	imported, err := models.ImportExchangeRates(h.DB, h.Settings, file)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "CSV file is too large"})
+			return
+		}
+		respondExchangeRateError(c, err, "Failed to import exchange rates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rates imported successfully", "imported": imported})
+}
+
+func respondExchangeRateError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, money.ErrUnknownCurrency),
+		errors.Is(err, money.ErrInvalidRate),
+		errors.Is(err, models.ErrBaseCurrencyRate),
+		errors.Is(err, models.ErrInvalidRateFile):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"NomadShop/listing"
 	"NomadShop/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,16 +18,29 @@ func NewFavoriteItemHandler(db *gorm.DB) *FavoriteItemHandler {
 	return &FavoriteItemHandler{DB: db}
 }
 
+var favoriteItemListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id": {Column: "id", Kind: listing.Int},
+	},
+	Filters: []listing.Filter{
+		{Param: "user_id", Column: "user_id", Kind: listing.Int},
+		{Param: "variant_id", Column: "variant_id", Kind: listing.Int, Op: listing.In},
+	},
+}
+
 func (fh *FavoriteItemHandler) GetAllFavoriteItems(c *gin.Context) {
-	var favoriteItems []models.FavoriteItem
+	query, ok := listQuery(c, favoriteItemListing)
+	if !ok {
+		return
+	}
 
-	err := fh.DB.Preload("Product").Preload("Product.Category").Find(&favoriteItems).Error
+	page, err := listing.Find[models.FavoriteItem](fh.DB, query, preload("Product", "Product.Category", "Variant"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching all favorite items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, favoriteItems)
+	c.JSON(http.StatusOK, page)
 }
 
 func (fh *FavoriteItemHandler) GetFavoriteItemByID(c *gin.Context) {
@@ -38,36 +52,38 @@ func (fh *FavoriteItemHandler) GetFavoriteItemByID(c *gin.Context) {
 	}
 
 	var favoriteItem models.FavoriteItem
-	err = fh.DB.Preload("Product").Preload("Product.Category").First(&favoriteItem, id).Error
+	err = fh.DB.Preload("Product").Preload("Product.Category").Preload("Variant").First(&favoriteItem, id).Error
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Favorite item not found"})
 		return
 	}
 
+	if allowed, err := canAccess(c, fh.DB, favoriteItem.UserID, models.PermUserRead); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Favorite item not found"})
+		return
+	}
+
 	c.JSON(http.StatusOK, favoriteItem)
 }
 
 func (fh *FavoriteItemHandler) GetFavoriteItemsByUser(c *gin.Context) {
-	userIDStr := c.DefaultQuery("user_id", "")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "User ID is required"})
+	userID, ok := targetUserID(c, fh.DB, c.Query("user_id"), models.PermUserRead)
+	if !ok {
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+	query, ok := listQuery(c, favoriteItemListing)
+	if !ok {
 		return
 	}
 
-	var favoriteItems []models.FavoriteItem
-	err = fh.DB.Preload("Product").Preload("Product.Category").Where("user_id = ?", userID).Find(&favoriteItems).Error
+	page, err := listing.Find[models.FavoriteItem](fh.DB.Where("user_id = ?", userID), query, preload("Product", "Product.Category", "Variant"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching favorite items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, favoriteItems)
+	c.JSON(http.StatusOK, page)
 }
 
 func (fh *FavoriteItemHandler) GetFavoriteItemsByProduct(c *gin.Context) {
@@ -83,22 +99,32 @@ func (fh *FavoriteItemHandler) GetFavoriteItemsByProduct(c *gin.Context) {
 		return
 	}
 
-	var favoriteItems []models.FavoriteItem
-	err = fh.DB.Preload("Product").Preload("Product.Category").Where("product_id = ?", productID).Find(&favoriteItems).Error
+	query, ok := listQuery(c, favoriteItemListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.FavoriteItem](fh.DB.Where("product_id = ?", productID), query, preload("Product", "Product.Category", "Variant"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching favorite items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, favoriteItems)
+	c.JSON(http.StatusOK, page)
 }
 
 func (fh *FavoriteItemHandler) CreateFavoriteItem(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	var favoriteItem models.FavoriteItem
 	if err := c.ShouldBindJSON(&favoriteItem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
+	favoriteItem.UserID = userID
 
 	var product models.Product
 	if err := fh.DB.First(&product, favoriteItem.ProductID).Error; err != nil {
@@ -112,6 +138,14 @@ func (fh *FavoriteItemHandler) CreateFavoriteItem(c *gin.Context) {
 		return
 	}
 
+	if favoriteItem.VariantID != nil {
+		if _, err := models.ResolveVariant(fh.DB, product.ID, *favoriteItem.VariantID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+			return
+		}
+	}
+	favoriteItem.Variant = nil
+
 	createdItem, err := models.AddToFavorites(fh.DB, &favoriteItem)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating favorite item"})
@@ -119,7 +153,7 @@ func (fh *FavoriteItemHandler) CreateFavoriteItem(c *gin.Context) {
 	}
 
 	var fullFavoriteItem models.FavoriteItem
-	err = fh.DB.Preload("Product").Preload("Product.Category").First(&fullFavoriteItem, createdItem.ID).Error
+	err = fh.DB.Preload("Product").Preload("Product.Category").Preload("Variant").First(&fullFavoriteItem, createdItem.ID).Error
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching favorite item with product and category"})
 		return
@@ -138,12 +172,18 @@ func (fh *FavoriteItemHandler) DeleteFavoriteItem(c *gin.Context) {
 
 	// ID бойынша сүйікті өнімді алу
 	var favoriteItem models.FavoriteItem
-	err = fh.DB.Preload("Product").Preload("Product.Category").First(&favoriteItem, id).Error
+	err = fh.DB.Preload("Product").Preload("Product.Category").Preload("Variant").First(&favoriteItem, id).Error
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Favorite item not found"})
 		return
 	}
 
+	// Басқа пайдаланушының жазбасы бар екенін ашпау үшін 404 қайтарылады
+	if allowed, err := canAccess(c, fh.DB, favoriteItem.UserID, models.PermUserManage); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Favorite item not found"})
+		return
+	}
+
 	// Сүйікті өнімді өшіру
 	if err := fh.DB.Delete(&favoriteItem).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error deleting favorite item"})
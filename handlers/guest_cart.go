@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"NomadShop/auth"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Қонақ себетінің токені cookie арқылы немесе cookie қолданбайтын клиенттер үшін тақырыппен беріледі
+const (
+	cartTokenCookie = "cart_token"
+	cartTokenHeader = "X-Cart-Token"
+)
+
+type GuestCartHandler struct {
+	DB       *gorm.DB
+	Settings models.Settings
+}
+
+func NewGuestCartHandler(db *gorm.DB, settings models.Settings) *GuestCartHandler {
+	return &GuestCartHandler{DB: db, Settings: settings}
+}
+
+func (h *GuestCartHandler) GetGuestCart(c *gin.Context) {
+	cart, ok := h.guestCart(c, false)
+	if !ok {
+		return
+	}
+
+	respondInCurrency(c, h.DB, h.Settings, cart)
+}
+
+// AddGuestCartItem жолды қосады. Токен жоқ немесе мерзімі өткен болса, жаңа себет жасалып,
+// оның токені cookie мен X-Cart-Token тақырыбында қайтарылады.
+func (h *GuestCartHandler) AddGuestCartItem(c *gin.Context) {
+	var item models.GuestCartItem
+	if err := c.ShouldBindJSON(&item); err != nil || item.Quantity == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	cart, ok := h.guestCart(c, true)
+	if !ok {
+		return
+	}
+
+	added, err := models.AddGuestCartItem(h.DB, cart, &item)
+	if err != nil {
+		respondAddGuestCartItemError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, added)
+}
+
+func respondAddGuestCartItemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrProductNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Product not found"})
+	case errors.Is(err, models.ErrVariantNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+	case errors.Is(err, models.ErrInsufficientStock):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Not enough stock"})
+	case errors.Is(err, models.ErrAlreadyInCart):
+		c.JSON(http.StatusConflict, gin.H{"message": "Product already in cart"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating cart item"})
+	}
+}
+
+func (h *GuestCartHandler) UpdateGuestCartItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+
+	var input models.GuestCartItem
+	if err := c.ShouldBindJSON(&input); err != nil || input.Quantity == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	cart, ok := h.guestCart(c, false)
+	if !ok {
+		return
+	}
+
+	item, err := models.UpdateGuestCartItem(h.DB, cart, uint(id), input.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrGuestCartItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Cart item not found"})
+		case errors.Is(err, models.ErrInsufficientStock):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Not enough stock to update quantity"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error updating cart item"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+func (h *GuestCartHandler) DeleteGuestCartItem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+
+	cart, ok := h.guestCart(c, false)
+	if !ok {
+		return
+	}
+
+	if err := models.DeleteGuestCartItem(h.DB, cart, uint(id)); err != nil {
+		if errors.Is(err, models.ErrGuestCartItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Cart item not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error deleting cart item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cart item deleted"})
+}
+
+// GetGuestCartSummary қонақ себетінің есебі, GET /cart/summary сияқты (купонсыз)
+func (h *GuestCartHandler) GetGuestCartSummary(c *gin.Context) {
+	cart, ok := h.guestCart(c, false)
+	if !ok {
+		return
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	summary, err := models.SummarizeGuestCart(h.DB, h.Settings, cart, currency)
+	if err != nil {
+		respondQuoteError(c, err, currency, "Failed to get cart summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// guestCart сұраудағы токен бойынша себетті табады. create болса, себет табылмағанда жаңасы
+// жасалады; әйтпесе 404 жауабы жазылады.
+func (h *GuestCartHandler) guestCart(c *gin.Context, create bool) (*models.GuestCart, bool) {
+	if token := cartToken(c); token != "" {
+		cart, err := models.GetGuestCart(h.DB, h.Settings, auth.HashCartToken(token))
+		if err == nil {
+			return cart, true
+		}
+		if !errors.Is(err, models.ErrGuestCartNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching cart"})
+			return nil, false
+		}
+	}
+	if !create {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Cart not found"})
+		return nil, false
+	}
+
+	token, tokenHash, err := auth.NewCartToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating cart"})
+		return nil, false
+	}
+	cart, err := models.CreateGuestCart(h.DB, tokenHash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating cart"})
+		return nil, false
+	}
+	setCartToken(c, token, h.Settings.GuestCartTTL)
+	return cart, true
+}
+
+func cartToken(c *gin.Context) string {
+	if token := c.GetHeader(cartTokenHeader); token != "" {
+		return token
+	}
+	token, _ := c.Cookie(cartTokenCookie)
+	return token
+}
+
+func setCartToken(c *gin.Context, token string, ttl time.Duration) {
+	c.Header(cartTokenHeader, token)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cartTokenCookie, token, int(ttl.Seconds()), "/", "", c.Request.TLS != nil, true)
+}
+
+func clearCartToken(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(cartTokenCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+}
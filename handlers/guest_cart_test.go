@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRespondAddGuestCartItemError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{models.ErrAlreadyInCart, http.StatusConflict},
+		{fmt.Errorf("adding item: %w", models.ErrAlreadyInCart), http.StatusConflict},
+		{models.ErrProductNotFound, http.StatusBadRequest},
+		{models.ErrVariantNotFound, http.StatusBadRequest},
+		{&models.StockError{ProductID: 1, VariantID: 2, Requested: 3, Available: 1}, http.StatusBadRequest},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondAddGuestCartItemError(c, tt.err)
+		if w.Code != tt.status {
+			t.Errorf("respondAddGuestCartItemError(%v) status = %d, want %d", tt.err, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"NomadShop/listing"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// listQuery тізім параметрлерін (limit, cursor, sort, сүзгілер) оқиды.
+// Параметрлер қате болса, 400 жауабын өзі жазады.
+func listQuery(c *gin.Context, spec listing.Spec) (*listing.Query, bool) {
+	query, err := listing.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return nil, false
+	}
+	return query, true
+}
+
+// inCurrency spec-тегі listing.Money сүзгілерін currency валютасында оқиды
+func inCurrency(spec listing.Spec, currency string) listing.Spec {
+	spec.Currency = currency
+	return spec
+}
+
+func preload(associations ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, association := range associations {
+			db = db.Preload(association)
+		}
+		return db
+	}
+}
@@ -1,83 +1,169 @@
 package handlers
 
 import (
+	"NomadShop/listing"
+	"NomadShop/middleware"
 	"NomadShop/models"
+	"NomadShop/money"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 type OrderHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Settings models.Settings
 }
 
-func NewOrderHandler(db *gorm.DB) *OrderHandler {
-	return &OrderHandler{DB: db}
+func NewOrderHandler(db *gorm.DB, settings models.Settings) *OrderHandler {
+	return &OrderHandler{DB: db, Settings: settings}
 }
 
+// CreateOrder себетсіз тапсырыс жасайды. Жолдардан тек өнім, нұсқа және сан алынады:
+// бағалар, жеңілдіктер мен жиынтық Checkout сияқты серверде есептеледі.
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	var order models.Order
 	if err := c.ShouldBindJSON(&order); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
+	for _, item := range order.OrderItems {
+		if item.Quantity == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Quantity must be positive"})
+			return
+		}
+	}
+
+	currency := strings.ToUpper(c.Query("currency"))
+	created, err := models.CreateOrder(h.DB, h.Settings, userID, order.OrderItems, models.CheckoutOptions{Currency: currency})
+	if err != nil {
+		if errors.Is(err, models.ErrCartEmpty) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Order has no items"})
+			return
+		}
+		if errors.Is(err, models.ErrVariantNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+			return
+		}
+		respondOrderError(c, err, currency, userID, "Error creating order")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Order created successfully", "order": created})
+}
 
-	// Тапсырысты базада сақтау
-	if err := h.DB.Create(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating order"})
+// Checkout себетті сервер есептеген бағалармен тапсырысқа айналдырады.
+// ?currency=USD берілсе, тапсырыс сол валютада қазіргі бағаммен рәсімделеді,
+// ал coupon_code берілсе, купон жеңілдігі тапсырыс сомасына кіреді.
+func (h *OrderHandler) Checkout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Егер OrderItems болса — оларды OrderID мен байланыстыру
-	for i := range order.OrderItems {
-		order.OrderItems[i].OrderID = order.ID
-		if err := h.DB.Create(&order.OrderItems[i]).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error saving order items"})
+	var input struct {
+		City       string `json:"city"`
+		CouponCode string `json:"coupon_code"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 			return
 		}
+	}
 
-		// Product және Category ақпаратын жүктеу
-		if err := h.DB.Preload("Product").Preload("Product.Category").First(&order.OrderItems[i], order.OrderItems[i].ID).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error loading product data"})
+	currency := strings.ToUpper(c.Query("currency"))
+	order, err := models.Checkout(h.DB, h.Settings, userID, models.CheckoutOptions{
+		City:       input.City,
+		Currency:   currency,
+		CouponCode: input.CouponCode,
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrCartEmpty) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Cart is empty"})
 			return
 		}
+		respondOrderError(c, err, currency, userID, "Error during checkout")
+		return
 	}
 
-	// Дайын тапсырысты толық мәліметімен қайтадан жүктеу (User, OrderItems)
-	var fullOrder models.Order
-	if err := h.DB.Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Product.Category").First(&fullOrder, order.ID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error loading full order data"})
+	c.JSON(http.StatusOK, gin.H{"message": "Order created successfully", "order": order})
+}
+
+// respondOrderError тапсырыс жасау кезіндегі қателерге жауап береді
+func respondOrderError(c *gin.Context, err error, currency string, userID uint, fallback string) {
+	var stockErr *models.StockError
+	if respondCouponError(c, err) {
 		return
 	}
+	switch {
+	case errors.Is(err, money.ErrUnknownCurrency):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown currency " + currency})
+	case errors.Is(err, money.ErrNoRate):
+		c.JSON(http.StatusBadRequest, gin.H{"message": "No exchange rate for " + currency})
+	case errors.As(err, &stockErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"message":    "Not enough stock",
+			"product_id": stockErr.ProductID,
+			"variant_id": stockErr.VariantID,
+			"requested":  stockErr.Requested,
+			"available":  stockErr.Available,
+		})
+	case errors.Is(err, models.ErrProductUnavailable):
+		c.JSON(http.StatusConflict, gin.H{"message": "Some products are no longer available"})
+	default:
+		log.Printf("Order creation failed for user %d: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order created successfully", "order": fullOrder})
+var orderListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":         {Column: "id", Kind: listing.Int},
+		"order_date": {Column: "order_date", Kind: listing.Time},
+		"total":      {Column: "total_amount", Kind: listing.Int},
+		"status":     {Column: "status", Kind: listing.String},
+	},
+	DefaultSort: "-order_date",
+	Filters: []listing.Filter{
+		{Param: "status", Column: "status", Kind: listing.String, Op: listing.In},
+		{Param: "user_id", Column: "user_id", Kind: listing.Int},
+		{Param: "total_min", Column: "total_amount", Kind: listing.Money, Op: listing.Gte},
+		{Param: "total_max", Column: "total_amount", Kind: listing.Money, Op: listing.Lte},
+		{Param: "date_from", Column: "order_date", Kind: listing.Time, Op: listing.Gte},
+		{Param: "date_to", Column: "order_date", Kind: listing.Time, Op: listing.Lte},
+	},
 }
 
 func (h *OrderHandler) GetOrdersByUser(c *gin.Context) {
-	userIDStr := c.DefaultQuery("user_id", "")
-	if userIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing user_id parameter"})
+	userID, ok := targetUserID(c, h.DB, c.Query("user_id"), models.PermOrderRead)
+	if !ok {
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+	query, ok := listQuery(c, inCurrency(orderListing, h.Settings.Currency))
+	if !ok {
 		return
 	}
 
-	var orders []models.Order
-	if err := h.DB.
-		Preload("User").
-		Preload("OrderItems.Product"). // Product ақпаратын жүктеу
-		Preload("OrderItems.Product.Category"). // Category ақпаратын жүктеу
-		Where("user_id = ?", userID).Find(&orders).Error; err != nil {
+	// User, Product және Category ақпаратын жүктеу
+	page, err := listing.Find[models.Order](h.DB.Where("user_id = ?", userID), query,
+		preload("User", "OrderItems.Product", "OrderItems.Product.Category", "OrderItems.Variant"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching orders"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orders)
+	respondInCurrency(c, h.DB, h.Settings, page)
 }
 
 func (h *OrderHandler) GetOrderByID(c *gin.Context) {
@@ -101,22 +187,39 @@ func (h *OrderHandler) GetOrderByID(c *gin.Context) {
 		Preload("OrderItems.Product").
 		Preload("OrderItems.Product.Category").
 		First(&order, orderID).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching order"})
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching order"})
+		}
+		return
+	}
+
+	if allowed, err := canAccess(c, h.DB, order.UserID, models.PermOrderRead); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, order)
+	respondInCurrency(c, h.DB, h.Settings, order)
 }
 
 func (h *OrderHandler) GetAllOrders(c *gin.Context) {
-	var orders []models.Order
-	if err := h.DB.Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Product.Category").Find(&orders).Error; err != nil {
+	query, ok := listQuery(c, inCurrency(orderListing, h.Settings.Currency))
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Order](h.DB, query,
+		preload("User", "OrderItems.Product", "OrderItems.Product.Category", "OrderItems.Variant"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching orders"})
 		return
 	}
-	c.JSON(http.StatusOK, orders)
+	respondInCurrency(c, h.DB, h.Settings, page)
 }
 
+// UpdateOrder ескі клиенттер үшін қалдырылған: тек күйді өзгертеді және ол да өмірлік цикл
+// ережелеріне бағынады. Total серверде есептеледі, оны өзгертуге болмайды.
 func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 	orderIDStr := c.Param("order_id")
 	orderID, err := strconv.Atoi(orderIDStr)
@@ -126,27 +229,112 @@ func (h *OrderHandler) UpdateOrder(c *gin.Context) {
 	}
 
 	var updatedOrder models.Order
-	if err := c.ShouldBindJSON(&updatedOrder); err != nil {
+	if err := c.ShouldBindJSON(&updatedOrder); err != nil || updatedOrder.Status == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	h.transition(c, uint(orderID), updatedOrder.Status, "")
+}
+
+type transitionRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"`
+}
+
+func (h *OrderHandler) TransitionOrder(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid order ID"})
+		return
+	}
+
+	var input transitionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
 
-	var existingOrder models.Order
-	if err := h.DB.First(&existingOrder, orderID).Error; err != nil {
+	h.transition(c, uint(orderID), input.Status, input.Note)
+}
+
+func (h *OrderHandler) GetOrderHistory(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid order ID"})
+		return
+	}
+
+	var order models.Order
+	if err := h.DB.First(&order, orderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+		return
+	}
+	if allowed, err := canAccess(c, h.DB, order.UserID, models.PermOrderRead); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+		return
+	}
+
+	history, err := models.GetOrderStatusHistory(h.DB, order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching order history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *OrderHandler) transition(c *gin.Context, orderID uint, status, note string) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if !models.IsOrderStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown order status"})
+		return
+	}
+
+	var order models.Order
+	if err := h.DB.First(&order, orderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+		return
+	}
+	if allowed, err := canAccess(c, h.DB, order.UserID, models.PermOrderRead); err != nil || !allowed {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
 		return
 	}
 
-	// Қолмен жаңарту
-	existingOrder.Status = updatedOrder.Status
-	existingOrder.Total = updatedOrder.Total
+	// Сатып алушы тек өз тапсырысын төлемге дейін ғана болдырмай алады,
+	// қайтаруға жеке рұқсат керек, қалғанының бәрі қызметкерлерге арналған
+	switch {
+	case status == models.OrderStatusRefunded:
+		if !middleware.RequirePermission(c, h.DB, models.PermOrderRefund) {
+			return
+		}
+	case status == models.OrderStatusCancelled && order.UserID == userID && order.Status == models.OrderStatusPending:
+	default:
+		if !middleware.RequirePermission(c, h.DB, models.PermOrderWrite) {
+			return
+		}
+	}
 
-	if err := h.DB.Save(&existingOrder).Error; err != nil {
+	updatedOrder, err := models.TransitionOrder(h.DB, order.ID, status, userID, note)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTransition) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+		if errors.Is(err, models.ErrReservationExpired) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Stock reservation has expired, please place the order again"})
+			return
+		}
+		log.Printf("Error changing status of order %d: %v", order.ID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully", "order": existingOrder})
+	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully", "order": updatedOrder})
 }
 
 func (h *OrderHandler) DeleteOrder(c *gin.Context) {
@@ -157,14 +345,12 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	// Алдымен OrderItem-дерді жою
-	if err := h.DB.Where("order_id = ?", orderID).Delete(&models.OrderItem{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete order items"})
-		return
-	}
-
-	// Сосын тапсырыстың өзін жою
-	if err := h.DB.Delete(&models.Order{}, orderID).Error; err != nil {
+	// Тапсырысты жою және қорды қайтару бір транзакцияда орындалады
+	if err := models.DeleteOrder(h.DB, uint(orderID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Order not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete order"})
 		return
 	}
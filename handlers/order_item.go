@@ -1,30 +1,53 @@
 package handlers
 
 import (
+	"NomadShop/listing"
 	"NomadShop/models"
+	"NomadShop/money"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"net/http"
 	"strconv"
 )
 
 type OrderItemHandler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Settings models.Settings
 }
 
-func NewOrderItemHandler(db *gorm.DB) *OrderItemHandler {
-	return &OrderItemHandler{DB: db}
+func NewOrderItemHandler(db *gorm.DB, settings models.Settings) *OrderItemHandler {
+	return &OrderItemHandler{DB: db, Settings: settings}
+}
+
+var orderItemListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":       {Column: "id", Kind: listing.Int},
+		"price":    {Column: "price_amount", Kind: listing.Int},
+		"quantity": {Column: "quantity", Kind: listing.Int},
+	},
+	Filters: []listing.Filter{
+		{Param: "order_id", Column: "order_id", Kind: listing.Int, Op: listing.In},
+		{Param: "variant_id", Column: "variant_id", Kind: listing.Int, Op: listing.In},
+		{Param: "warehouse_id", Column: "warehouse_id", Kind: listing.Int, Op: listing.In},
+		{Param: "price_min", Column: "price_amount", Kind: listing.Money, Op: listing.Gte},
+		{Param: "price_max", Column: "price_amount", Kind: listing.Money, Op: listing.Lte},
+	},
 }
 
 func (h *OrderItemHandler) GetAllOrderItems(c *gin.Context) {
-	var orderItems []models.OrderItem
+	query, ok := listQuery(c, inCurrency(orderItemListing, h.Settings.Currency))
+	if !ok {
+		return
+	}
 
-	if err := h.DB.Preload("Product.Category").Find(&orderItems).Error; err != nil {
+	page, err := listing.Find[models.OrderItem](h.DB, query, preload("Product.Category", "Variant"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to fetch order items"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orderItems)
+	respondInCurrency(c, h.DB, h.Settings, page)
 }
 
 func (h *OrderItemHandler) CreateOrderItem(c *gin.Context) {
@@ -34,8 +57,19 @@ func (h *OrderItemHandler) CreateOrderItem(c *gin.Context) {
 		return
 	}
 
+	// Нұсқа көрсетілмесе, өнімнің әдепкі нұсқасы алынады
+	variant, err := models.ResolveVariant(h.DB, orderItem.ProductID, orderItem.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+		return
+	}
+	orderItem.VariantID = variant.ID
+	if !h.matchOrderCurrency(c, &orderItem) {
+		return
+	}
+
 	// OrderItem-ді базада сақтау
-	if err := h.DB.Create(&orderItem).Error; err != nil {
+	if err := h.DB.Omit(clause.Associations).Create(&orderItem).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating order item"})
 		return
 	}
@@ -44,6 +78,7 @@ func (h *OrderItemHandler) CreateOrderItem(c *gin.Context) {
 	if err := h.DB.
 		Preload("Product").
 		Preload("Product.Category").
+		Preload("Variant").
 		First(&orderItem, orderItem.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error loading product data"})
 		return
@@ -68,6 +103,17 @@ func (h *OrderItemHandler) GetOrderItemsByOrderID(c *gin.Context) {
 		return
 	}
 
+	// Тапсырыс иесін тексеру
+	var order models.Order
+	if err := h.DB.First(&order, orderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "No order items found for the provided order ID"})
+		return
+	}
+	if allowed, err := canAccess(c, h.DB, order.UserID, models.PermOrderRead); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "No order items found for the provided order ID"})
+		return
+	}
+
 	var orderItems []models.OrderItem
 	if err := h.DB.
 		Preload("Product").
@@ -83,7 +129,7 @@ func (h *OrderItemHandler) GetOrderItemsByOrderID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, orderItems)
+	respondInCurrency(c, h.DB, h.Settings, orderItems)
 }
 
 func (h *OrderItemHandler) GetOrderItemsByProductID(c *gin.Context) {
@@ -99,22 +145,24 @@ func (h *OrderItemHandler) GetOrderItemsByProductID(c *gin.Context) {
 		return
 	}
 
-	var orderItems []models.OrderItem
-	if err := h.DB.
-		Preload("Product").
-		Preload("Product.Category").
-		Where("product_id = ?", productID).
-		Find(&orderItems).Error; err != nil {
+	query, ok := listQuery(c, inCurrency(orderItemListing, h.Settings.Currency))
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.OrderItem](h.DB.Where("product_id = ?", productID), query,
+		preload("Product", "Product.Category", "Variant"))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching order items"})
 		return
 	}
 
-	if len(orderItems) == 0 {
+	if page.Total == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No order items found for the provided product ID"})
 		return
 	}
 
-	c.JSON(http.StatusOK, orderItems)
+	respondInCurrency(c, h.DB, h.Settings, page)
 }
 
 func (h *OrderItemHandler) UpdateOrderItem(c *gin.Context) {
@@ -137,9 +185,19 @@ func (h *OrderItemHandler) UpdateOrderItem(c *gin.Context) {
 		return
 	}
 
+	variant, err := models.ResolveVariant(h.DB, updatedData.ProductID, updatedData.VariantID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+		return
+	}
+
 	existingOrderItem.ProductID = updatedData.ProductID
+	existingOrderItem.VariantID = variant.ID
 	existingOrderItem.Quantity = updatedData.Quantity
 	existingOrderItem.Price = updatedData.Price
+	if !h.matchOrderCurrency(c, &existingOrderItem) {
+		return
+	}
 
 	if err := h.DB.Save(&existingOrderItem).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update order item"})
@@ -167,3 +225,30 @@ func (h *OrderItemHandler) DeleteOrderItem(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order item deleted successfully"})
 }
+
+// matchOrderCurrency жолдың бағасын тапсырыстың валютасымен тексереді. Валюта берілмесе,
+// сомалар тапсырыстың валютасында оқылады. Сәйкес келмесе, 400 жауабын өзі жазады.
+func (h *OrderItemHandler) matchOrderCurrency(c *gin.Context, item *models.OrderItem) bool {
+	var order models.Order
+	if err := h.DB.Select("id", "total_currency").First(&order, item.OrderID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Order not found"})
+		return false
+	}
+	currency := order.Total.Currency
+	amounts := []*money.Money{&item.Price, &item.Discount}
+	for i := range item.Discounts {
+		amounts = append(amounts, &item.Discounts[i].Amount)
+	}
+	for _, amount := range amounts {
+		resolved, err := amount.Resolve(currency)
+		if err != nil || resolved.Currency != currency {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Order item price must be in " + currency})
+			return false
+		}
+		*amount = resolved
+	}
+	if item.Discounts == nil {
+		item.Discounts = []models.LineDiscount{}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"NomadShop/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentUserID аутентификацияланған пайдаланушыны қайтарады, болмаса 401 жазады
+func currentUserID(c *gin.Context) (uint, bool) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// targetUserID сұралған пайдаланушыны анықтайды. Бос болса — ағымдағы пайдаланушы,
+// басқа пайдаланушының деректерін тек overridePermission бар қызметкер көре алады.
+func targetUserID(c *gin.Context, db *gorm.DB, requested string, overridePermission string) (uint, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return 0, false
+	}
+	if requested == "" {
+		return userID, true
+	}
+
+	requestedID, err := strconv.Atoi(requested)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid user ID"})
+		return 0, false
+	}
+	if uint(requestedID) == userID {
+		return userID, true
+	}
+
+	if !middleware.RequirePermission(c, db, overridePermission) {
+		return 0, false
+	}
+	return uint(requestedID), true
+}
+
+// canAccess жазба ағымдағы пайдаланушыға тиесілі ме, әлде оның overridePermission рұқсаты бар ма
+func canAccess(c *gin.Context, db *gorm.DB, ownerID uint, overridePermission string) (bool, error) {
+	principal, ok := middleware.CurrentPrincipal(c)
+	if !ok {
+		return false, nil
+	}
+	if principal.UserID == ownerID {
+		return true, nil
+	}
+	return principal.HasPermission(db, overridePermission)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"NomadShop/listing"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type PermissionHandler struct {
+	DB *gorm.DB
+}
+
+func NewPermissionHandler(db *gorm.DB) *PermissionHandler {
+	return &PermissionHandler{DB: db}
+}
+
+var permissionListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":   {Column: "id", Kind: listing.Int},
+		"name": {Column: "name", Kind: listing.String},
+	},
+}
+
+func (h *PermissionHandler) GetAllPermissions(c *gin.Context) {
+	query, ok := listQuery(c, permissionListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Permission](h.DB, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *PermissionHandler) GetPermissionByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid permission ID"})
+		return
+	}
+
+	permission, err := models.GetPermissionByID(h.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Permission not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permission)
+}
+
+func (h *PermissionHandler) CreatePermission(c *gin.Context) {
+	var permission models.Permission
+	if err := c.ShouldBindJSON(&permission); err != nil || permission.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if _, err := models.CreatePermission(h.DB, &permission); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, permission)
+}
+
+func (h *PermissionHandler) UpdatePermission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid permission ID"})
+		return
+	}
+
+	var permission models.Permission
+	if err := c.ShouldBindJSON(&permission); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if _, err := models.GetPermissionByID(h.DB, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Permission not found"})
+		return
+	}
+
+	updatedPermission, err := models.UpdatePermission(h.DB, uint(id), &permission)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedPermission)
+}
+
+func (h *PermissionHandler) DeletePermission(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid permission ID"})
+		return
+	}
+
+	if err := models.DeletePermission(h.DB, uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permission deleted"})
+}
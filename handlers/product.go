@@ -1,34 +1,172 @@
 package handlers
 
 import (
+	"errors"
 	"log"
+	"maps"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"NomadShop/listing"
+	"NomadShop/media"
+	"NomadShop/middleware"
 	"NomadShop/models"
+	"NomadShop/money"
+	"NomadShop/storage"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type Handler struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Storage  storage.Storage
+	Settings models.Settings
+}
+
+// Түс пен өлшем бойынша сүзгі өнімнің кез келген нұсқасына қолданылады
+var productListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":    {Column: "products.id", Kind: listing.Int},
+		"name":  {Column: "products.name", Kind: listing.String},
+		"price": {Column: "products.price_amount", Kind: listing.Int},
+		"stock": {Column: "products.stock", Kind: listing.Int},
+	},
+	Filters: []listing.Filter{
+		{Param: "price_min", Column: "products.price_amount", Kind: listing.Money, Op: listing.Gte},
+		{Param: "price_max", Column: "products.price_amount", Kind: listing.Money, Op: listing.Lte},
+		{Param: "category_id", Column: "products.category_id", Kind: listing.Int, Op: listing.In},
+		{Param: "color", Kind: listing.String, Op: listing.In,
+			Where: "EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.color IN ?)"},
+		{Param: "size", Kind: listing.String, Op: listing.In,
+			Where: "EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.size IN ?)"},
+		{Param: "in_stock", Kind: listing.Bool, Where: "(products.stock > 0) = ?"},
+	},
+}
+
+// Іздеу нәтижелері әдепкіде сәйкестік бойынша сұрыпталады
+var productSearchListing = listing.Spec{
+	Sorts: func() map[string]listing.Field {
+		sorts := maps.Clone(productListing.Sorts)
+		sorts["relevance"] = listing.Field{Column: "products.search_rank", Kind: listing.Float}
+		return sorts
+	}(),
+	DefaultSort: "-relevance",
+	Filters:     productListing.Filters,
+}
+
+// productFacetFilters әр facet санағанда есепке алынбайтын сүзгі параметрлері
+var productFacetFilters = map[string][]string{
+	models.FacetCategory: {"category_id"},
+	models.FacetColor:    {"color"},
+	models.FacetSize:     {"size"},
+	models.FacetPrice:    {"price_min", "price_max"},
+	models.FacetInStock:  {"in_stock"},
+}
+
+type productPage struct {
+	*listing.Page[models.Product]
+	Facets map[string][]models.FacetValue `json:"facets,omitempty"`
+}
+
+// productFacets facets=true немесе facets=color,size берілсе, ағымдағы сүзгілер бойынша
+// facet сандарын есептейді. Баға аралықтары price_ranges=0-5000,5000- арқылы беріледі.
+func productFacets(c *gin.Context, db *gorm.DB, settings models.Settings, query *listing.Query) (map[string][]models.FacetValue, bool) {
+	raw := c.Query("facets")
+	if raw == "" || raw == "false" {
+		return nil, true
+	}
+
+	facets := models.AllFacets
+	if raw != "true" {
+		facets = nil
+		for _, facet := range strings.Split(raw, ",") {
+			if _, ok := productFacetFilters[facet]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"message": "Unknown facet " + facet})
+				return nil, false
+			}
+			facets = append(facets, facet)
+		}
+	}
+
+	ranges := models.DefaultPriceRanges
+	if rawRanges := c.Query("price_ranges"); rawRanges != "" {
+		var err error
+		if ranges, err = models.ParsePriceRanges(rawRanges); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return nil, false
+		}
+	}
+
+	scopes := models.FacetScopes{}
+	for facet, params := range productFacetFilters {
+		scopes[facet] = query.Filters(params...)
+	}
+
+	result, err := models.GetProductFacets(db, settings, facets, scopes, ranges)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to count facets"})
+		return nil, false
+	}
+	return result, true
 }
 
 func (h *Handler) GetProducts(c *gin.Context) {
-	var products []models.Product
-	err := h.DB.Preload("Category").Find(&products).Error
+	query, ok := listQuery(c, inCurrency(productListing, h.Settings.Currency))
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Product](h.DB, query, preload("Category", "Variants"), models.PreloadImages)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get products"})
 		return
 	}
 
-	for _, product := range products {
+	for _, product := range page.Items {
 		if product.Category.ID == 0 {
 			log.Println("No category for product:", product.Name)
 		}
 	}
 
-	c.JSON(http.StatusOK, products)
+	facets, ok := productFacets(c, h.DB, h.Settings, query)
+	if !ok {
+		return
+	}
+
+	respondInCurrency(c, h.DB, h.Settings, productPage{Page: page, Facets: facets})
+}
+
+// SearchProducts өнімдерді атауы мен сипаттамасы бойынша іздейді (q, қосымша lang=ru|kk|en).
+// Әдеттегі сүзгілер мен беттеу де қолданылады.
+func (h *Handler) SearchProducts(c *gin.Context) {
+	search, err := models.SearchProducts(c.Query("q"), c.Query("lang"))
+	if err != nil {
+		if errors.Is(err, models.ErrUnsupportedSearchLang) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Unsupported search language, use ru, kk or en"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Search query is required"})
+		return
+	}
+
+	query, ok := listQuery(c, productSearchListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Product](h.DB.Scopes(search), query, preload("Category", "Variants"), models.PreloadImages)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to search products"})
+		return
+	}
+
+	facets, ok := productFacets(c, h.DB.Scopes(search), h.Settings, query)
+	if !ok {
+		return
+	}
+
+	respondInCurrency(c, h.DB, h.Settings, productPage{Page: page, Facets: facets})
 }
 
 func (h *Handler) GetProductByID(c *gin.Context) {
@@ -39,9 +177,8 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		return
 	}
 
-	// Өнімді ID бойынша алу және категорияны алдын ала жүктеу
-	var product models.Product
-	err = h.DB.Preload("Category").First(&product, id).Error
+	// Өнімді ID бойынша алу, категория мен нұсқаларды алдын ала жүктеу
+	product, err := models.GetProductByID(h.DB, uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
@@ -51,7 +188,7 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	respondInCurrency(c, h.DB, h.Settings, product)
 }
 
 func (h *Handler) GetProductsByCategory(c *gin.Context) {
@@ -67,16 +204,36 @@ func (h *Handler) GetProductsByCategory(c *gin.Context) {
 		return
 	}
 
-	// category_id бойынша өнімдерді алу
-	var products []models.Product
-	err = h.DB.Where("category_id = ?", categoryID).Find(&products).Error
+	query, ok := listQuery(c, inCurrency(productListing, h.Settings.Currency))
+	if !ok {
+		return
+	}
+
+	// category_id бойынша өнімдерді алу; include_descendants=true болса, ішкі санаттардағы өнімдер де кіреді
+	inCategory := h.DB.Where("products.category_id = ?", categoryID)
+	if raw := c.Query("include_descendants"); raw != "" {
+		descendants, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid value for include_descendants"})
+			return
+		}
+		if descendants {
+			inCategory = h.DB.Where("products.category_id IN (?)", models.CategorySubtree(h.DB, uint(categoryID)))
+		}
+	}
+	page, err := listing.Find[models.Product](inCategory, query, preload("Variants"), models.PreloadImages)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching products"})
 		return
 	}
 
+	facets, ok := productFacets(c, inCategory, h.Settings, query)
+	if !ok {
+		return
+	}
+
 	// Продуктілерді қайтару
-	c.JSON(http.StatusOK, products)
+	respondInCurrency(c, h.DB, h.Settings, productPage{Page: page, Facets: facets})
 }
 
 func (h *Handler) CreateProduct(c *gin.Context) {
@@ -93,13 +250,22 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	createdProduct, err := models.CreateProduct(h.DB, &product)
+	actorID, _ := middleware.CurrentUserID(c)
+	createdProduct, err := models.CreateProduct(h.DB, h.Settings, &product, actorID)
 	if err != nil {
+		if errors.Is(err, models.ErrVariantDuplicate) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Variants must have distinct options"})
+			return
+		}
+		if errors.Is(err, money.ErrCurrencyMismatch) || errors.Is(err, money.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error creating product"})
 		return
 	}
 
-	err = h.DB.Preload("Category").First(&createdProduct, createdProduct.ID).Error
+	createdProduct, err = models.GetProductByID(h.DB, createdProduct.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to load category"})
 		return
@@ -116,11 +282,21 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	var updatedData models.Product
-	if err := c.ShouldBindJSON(&updatedData); err != nil {
+	// Stock көрсеткіш: берілмесе қор өзгермейді, ал 0 қорды нөлдейді
+	var input struct {
+		models.Product
+		Stock *int `json:"Stock"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Stock != nil && *input.Stock < 0) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
+	updatedData := input.Product
+	var stock *uint
+	if input.Stock != nil {
+		value := uint(*input.Stock)
+		stock = &value
+	}
 
 	// Категория бар-жоғын тексеру
 	if _, err := models.GetCategoryByID(h.DB, updatedData.CategoryID); err != nil {
@@ -128,8 +304,22 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := models.UpdateProduct(h.DB, uint(id), &updatedData)
+	actorID, _ := middleware.CurrentUserID(c)
+	product, err := models.UpdateProduct(h.DB, h.Settings, uint(id), &updatedData, stock, actorID)
 	if err != nil {
+		if errors.Is(err, models.ErrVariantRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Product has several variants, update stock per variant"})
+			return
+		}
+		var stockErr *models.StockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusConflict, gin.H{"message": "Warehouse stock does not cover the decrease", "available": stockErr.Available})
+			return
+		}
+		if errors.Is(err, money.ErrCurrencyMismatch) || errors.Is(err, money.ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update product"})
 		return
 	}
@@ -145,10 +335,124 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 		return
 	}
 
-	if err := models.DeleteProduct(h.DB, uint(id)); err != nil {
+	images, err := models.DeleteProduct(h.DB, uint(id))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete product"})
 		return
 	}
+	for i := range images {
+		media.DeleteFiles(c.Request.Context(), h.Storage, &images[i])
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
+
+var stockMovementListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":         {Column: "id", Kind: listing.Int},
+		"created_at": {Column: "created_at", Kind: listing.Time},
+	},
+	DefaultSort: "-id",
+	Filters: []listing.Filter{
+		{Param: "reason", Column: "reason", Kind: listing.String, Op: listing.In},
+		{Param: "variant_id", Column: "variant_id", Kind: listing.Int, Op: listing.In},
+		{Param: "warehouse_id", Column: "warehouse_id", Kind: listing.Int, Op: listing.In},
+		{Param: "order_id", Column: "order_id", Kind: listing.Int},
+		{Param: "date_from", Column: "created_at", Kind: listing.Time, Op: listing.Gte},
+		{Param: "date_to", Column: "created_at", Kind: listing.Time, Op: listing.Lte},
+	},
+}
+
+func (h *Handler) GetStockMovements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return
+	}
+
+	if _, err := models.GetProductByID(h.DB, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		return
+	}
+
+	query, ok := listQuery(c, stockMovementListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.StockMovement](h.DB.Where("product_id = ?", id), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get stock movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+type stockMovementRequest struct {
+	VariantID   uint   `json:"variant_id"`
+	WarehouseID uint   `json:"warehouse_id"`
+	Delta       int64  `json:"delta" binding:"required"`
+	Reason      string `json:"reason" binding:"required"`
+	Note        string `json:"note"`
+}
+
+// CreateStockMovement қорды қолмен өзгерту (түгендеу, қайтару, жеткізу)
+func (h *Handler) CreateStockMovement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return
+	}
+
+	var input stockMovementRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+	// Сату қозғалыстары тек тапсырыстар арқылы жасалады
+	if !models.IsMovementReason(input.Reason) || input.Reason == models.MovementSale {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Reason must be one of return, adjustment, import"})
+		return
+	}
+
+	// variant_id берілмесе, әдепкі нұсқа, warehouse_id берілмесе, басым қойма қолданылады
+	if input.WarehouseID != 0 {
+		if _, err := models.GetWarehouseByID(h.DB, input.WarehouseID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Warehouse not found"})
+			return
+		}
+	}
+
+	movement := models.StockMovement{
+		ProductID:   uint(id),
+		VariantID:   input.VariantID,
+		WarehouseID: input.WarehouseID,
+		Delta:       input.Delta,
+		Reason:      input.Reason,
+		Note:        input.Note,
+	}
+	if actorID, ok := middleware.CurrentUserID(c); ok {
+		movement.UserID = &actorID
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		return models.ApplyStockMovement(tx, &movement)
+	})
+	if err != nil {
+		var stockErr *models.StockError
+		switch {
+		case errors.As(err, &stockErr):
+			c.JSON(http.StatusConflict, gin.H{"message": "Stock cannot become negative", "available": stockErr.Available})
+		case errors.Is(err, models.ErrProductUnavailable):
+			c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		case errors.Is(err, models.ErrVariantNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Variant not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to record stock movement"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, movement)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"NomadShop/media"
+	"NomadShop/models"
+	"NomadShop/storage"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProductImageHandler struct {
+	DB            *gorm.DB
+	Storage       storage.Storage
+	MaxUploadSize int64
+	MaxPixels     int
+}
+
+func NewProductImageHandler(db *gorm.DB, store storage.Storage, maxUploadSize int64, maxPixels int) *ProductImageHandler {
+	return &ProductImageHandler{DB: db, Storage: store, MaxUploadSize: maxUploadSize, MaxPixels: maxPixels}
+}
+
+func (h *ProductImageHandler) GetImages(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	images, err := models.GetProductImages(h.DB, productID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get images"})
+		return
+	}
+
+	c.JSON(http.StatusOK, images)
+}
+
+// UploadImage multipart/form-data сұрауының "image" өрісіндегі суретті сақтайды.
+// Кішірейтілген нұсқалар фонда жасалады, оған дейін сурет pending күйінде тұрады.
+func (h *ProductImageHandler) UploadImage(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	// Multipart тақырыптары үшін шамалы қор қалдырылады
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxUploadSize+1<<20)
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Image is too large", "max_size": h.MaxUploadSize})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Image file is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Image is too large", "max_size": h.MaxUploadSize})
+		return
+	}
+
+	format, width, height, err := media.Inspect(file, h.MaxPixels)
+	switch {
+	case errors.Is(err, media.ErrUnsupportedImage):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"message": "Image must be JPEG, PNG or WebP"})
+		return
+	case errors.Is(err, media.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Image dimensions are too large"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to read image"})
+		return
+	}
+
+	// Әр сурет жеке каталогта сақталады, сондықтан атаулар қайталанбайды және кэштеуге болады
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to store image"})
+		return
+	}
+	prefix := fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(suffix))
+	key := media.FileKey(prefix, "original", format)
+	if err := h.Storage.Put(c.Request.Context(), key, file, media.ContentType(format)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to store image"})
+		return
+	}
+
+	image := models.ProductImage{
+		ProductID:  productID,
+		StorageKey: prefix,
+		Format:     format,
+		Width:      width,
+		Height:     height,
+		AltText:    c.PostForm("alt_text"),
+		Status:     models.ImagePending,
+		URLs:       map[string]string{"original": h.Storage.URL(key)},
+		Files:      []string{key},
+	}
+	if _, err := models.CreateProductImage(h.DB, &image); err != nil {
+		media.DeleteFiles(c.Request.Context(), h.Storage, &image)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save image"})
+		return
+	}
+
+	c.JSON(http.StatusOK, image)
+}
+
+func (h *ProductImageHandler) UpdateImage(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.Atoi(c.Param("image_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid image ID"})
+		return
+	}
+
+	var input models.ProductImageUpdate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	image, err := models.UpdateProductImage(h.DB, productID, uint(imageID), &input)
+	if err != nil {
+		if errors.Is(err, models.ErrImageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Image not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update image"})
+		return
+	}
+
+	c.JSON(http.StatusOK, image)
+}
+
+func (h *ProductImageHandler) DeleteImage(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	imageID, err := strconv.Atoi(c.Param("image_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid image ID"})
+		return
+	}
+
+	image, err := models.DeleteProductImage(h.DB, productID, uint(imageID))
+	if err != nil {
+		if errors.Is(err, models.ErrImageNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Image not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete image"})
+		return
+	}
+	media.DeleteFiles(c.Request.Context(), h.Storage, image)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+func (h *ProductImageHandler) productID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return 0, false
+	}
+
+	if _, err := models.GetProductByID(h.DB, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"NomadShop/middleware"
+	"NomadShop/models"
+	"NomadShop/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProductVariantHandler struct {
+	DB       *gorm.DB
+	Settings models.Settings
+}
+
+func NewProductVariantHandler(db *gorm.DB, settings models.Settings) *ProductVariantHandler {
+	return &ProductVariantHandler{DB: db, Settings: settings}
+}
+
+func (h *ProductVariantHandler) GetVariants(c *gin.Context) {
+	product, ok := h.product(c)
+	if !ok {
+		return
+	}
+
+	variants, err := models.GetProductVariants(h.DB, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get variants"})
+		return
+	}
+
+	respondInCurrency(c, h.DB, h.Settings, variants)
+}
+
+func (h *ProductVariantHandler) CreateVariant(c *gin.Context) {
+	product, ok := h.product(c)
+	if !ok {
+		return
+	}
+
+	var variant models.ProductVariant
+	if err := c.ShouldBindJSON(&variant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+	variant.ID = 0
+	variant.ProductID = product.ID
+	variant.IsDefault = false
+
+	actorID, _ := middleware.CurrentUserID(c)
+	if _, err := models.CreateProductVariant(h.DB, h.Settings, &variant, actorID); err != nil {
+		respondVariantError(c, err, "Failed to create variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+// GenerateMatrix түстер мен өлшемдердің барлық комбинациялары үшін нұсқалар жасайды.
+// Бар комбинациялар өзгеріссіз қалады.
+func (h *ProductVariantHandler) GenerateMatrix(c *gin.Context) {
+	product, ok := h.product(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Colors []string     `json:"colors"`
+		Sizes  []string     `json:"sizes"`
+		Price  *money.Money `json:"price"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || len(input.Colors)+len(input.Sizes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Colors or sizes are required"})
+		return
+	}
+
+	actorID, _ := middleware.CurrentUserID(c)
+	created, err := models.GenerateVariantMatrix(h.DB, h.Settings, product.ID, input.Colors, input.Sizes, input.Price, actorID)
+	if err != nil {
+		respondVariantError(c, err, "Failed to generate variants")
+		return
+	}
+
+	variants, err := models.GetProductVariants(h.DB, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get variants"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"created": created, "variants": variants})
+}
+
+func (h *ProductVariantHandler) UpdateVariant(c *gin.Context) {
+	product, ok := h.product(c)
+	if !ok {
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid variant ID"})
+		return
+	}
+
+	var input models.ProductVariant
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	actorID, _ := middleware.CurrentUserID(c)
+	variant, err := models.UpdateProductVariant(h.DB, h.Settings, product.ID, uint(variantID), &input, actorID)
+	if err != nil {
+		respondVariantError(c, err, "Failed to update variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, variant)
+}
+
+func (h *ProductVariantHandler) DeleteVariant(c *gin.Context) {
+	product, ok := h.product(c)
+	if !ok {
+		return
+	}
+	variantID, err := strconv.Atoi(c.Param("variant_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid variant ID"})
+		return
+	}
+
+	if err := models.DeleteProductVariant(h.DB, product.ID, uint(variantID)); err != nil {
+		respondVariantError(c, err, "Failed to delete variant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Variant deleted successfully"})
+}
+
+func (h *ProductVariantHandler) product(c *gin.Context) (*models.Product, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return nil, false
+	}
+
+	product, err := models.GetProductByID(h.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		return nil, false
+	}
+	return product, true
+}
+
+func respondVariantError(c *gin.Context, err error, fallback string) {
+	var stockErr *models.StockError
+	switch {
+	case errors.Is(err, models.ErrVariantNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Variant not found"})
+	case errors.Is(err, models.ErrVariantDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"message": "Variant with these options already exists"})
+	case errors.Is(err, models.ErrDefaultVariant):
+		c.JSON(http.StatusConflict, gin.H{"message": "Default variant cannot be deleted"})
+	case errors.Is(err, models.ErrVariantInUse):
+		c.JSON(http.StatusConflict, gin.H{"message": "Variant has stock history or orders and cannot be deleted"})
+	case errors.Is(err, money.ErrCurrencyMismatch), errors.Is(err, money.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	case errors.As(err, &stockErr):
+		c.JSON(http.StatusConflict, gin.H{"message": "Stock cannot become negative", "available": stockErr.Available})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"NomadShop/listing"
+	"NomadShop/models"
+	"NomadShop/money"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PromotionHandler struct {
+	DB       *gorm.DB
+	Settings models.Settings
+}
+
+func NewPromotionHandler(db *gorm.DB, settings models.Settings) *PromotionHandler {
+	return &PromotionHandler{DB: db, Settings: settings}
+}
+
+var promotionListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":         {Column: "id", Kind: listing.Int},
+		"name":       {Column: "name", Kind: listing.String},
+		"priority":   {Column: "priority", Kind: listing.Int},
+		"created_at": {Column: "created_at", Kind: listing.Time},
+	},
+	DefaultSort: "-priority",
+	Filters: []listing.Filter{
+		{Param: "type", Column: "type", Kind: listing.String, Op: listing.In},
+		{Param: "active", Column: "active", Kind: listing.Bool},
+		{Param: "stackable", Column: "stackable", Kind: listing.Bool},
+	},
+}
+
+func (h *PromotionHandler) GetPromotions(c *gin.Context) {
+	query, ok := listQuery(c, promotionListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Promotion](h.DB, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *PromotionHandler) GetPromotionByID(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	promotion, err := models.GetPromotionByID(h.DB, id)
+	if err != nil {
+		respondPromotionError(c, err, "Failed to get promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var promotion models.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	created, err := models.CreatePromotion(h.DB, h.Settings, &promotion)
+	if err != nil {
+		respondPromotionError(c, err, "Failed to create promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	var promotion models.Promotion
+	if err := c.ShouldBindJSON(&promotion); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	updated, err := models.UpdatePromotion(h.DB, h.Settings, id, &promotion)
+	if err != nil {
+		respondPromotionError(c, err, "Failed to update promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeletePromotion акцияны жояды. Бұрынғы тапсырыстардағы жеңілдіктер жолдарда сақталып қалады.
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	id, ok := promotionID(c)
+	if !ok {
+		return
+	}
+
+	if err := models.DeletePromotion(h.DB, id); err != nil {
+		respondPromotionError(c, err, "Failed to delete promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted successfully"})
+}
+
+func respondPromotionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, models.ErrPromotionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"message": "Promotion not found"})
+	case errors.Is(err, models.ErrPromotionInvalid), errors.Is(err, money.ErrCurrencyMismatch), errors.Is(err, money.ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"message": fallback})
+	}
+}
+
+func promotionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid promotion ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"NomadShop/listing"
 	"NomadShop/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,13 +18,25 @@ func NewRoleHandler(db *gorm.DB) *RoleHandler {
 }
 
 
+var roleListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":   {Column: "id", Kind: listing.Int},
+		"name": {Column: "name", Kind: listing.String},
+	},
+}
+
 func (h *RoleHandler) GetAllRoles(c *gin.Context) {
-	roles, err := models.GetRoles(h.DB)
+	query, ok := listQuery(c, roleListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.Role](h.DB, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get roles"})
 		return
 	}
-	c.JSON(http.StatusOK, roles)
+	c.JSON(http.StatusOK, page)
 }
 
 
@@ -100,3 +113,57 @@ func (h *RoleHandler) DeleteRole(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
 }
+
+func (h *RoleHandler) AddRolePermission(c *gin.Context) {
+	roleID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid role ID"})
+		return
+	}
+
+	var input struct {
+		PermissionID uint `json:"permission_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if _, err := models.GetRoleByID(h.DB, uint(roleID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Role not found"})
+		return
+	}
+	if _, err := models.GetPermissionByID(h.DB, input.PermissionID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Permission not found"})
+		return
+	}
+
+	if err := models.AddRolePermission(h.DB, uint(roleID), input.PermissionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to add permission to role"})
+		return
+	}
+
+	role, err := models.GetRoleByID(h.DB, uint(roleID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to load role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *RoleHandler) RemoveRolePermission(c *gin.Context) {
+	roleID, err1 := strconv.Atoi(c.Param("id"))
+	permissionID, err2 := strconv.Atoi(c.Param("permission_id"))
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid ID format"})
+		return
+	}
+
+	if err := models.RemoveRolePermission(h.DB, uint(roleID), uint(permissionID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to remove permission from role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permission removed from role"})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"NomadShop/listing"
 	"NomadShop/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,14 +18,28 @@ func NewUserHandler(db *gorm.DB) *UserHandler {
 	return &UserHandler{DB: db}
 }
 
+// models.User.Password JSON-ға шықпайды, сондықтан кіріс деректері бөлек құрылымға байланады
+type userRequest struct {
+	Username string
+	Email    string
+	Password string
+}
+
 
 func (uh *UserHandler) CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input userRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
 
+	if input.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Password is required"})
+		return
+	}
+
+	user := models.User{Username: input.Username, Email: input.Email, Password: input.Password}
+
 	// Қайталанатын email немесе username тексеру
 	var existing models.User
 	if err := uh.DB.Where("email = ? OR username = ?", user.Email, user.Username).First(&existing).Error; err == nil {
@@ -51,6 +66,11 @@ func (uh *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	if allowed, err := canAccess(c, uh.DB, uint(id), models.PermUserRead); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+
 	user, err := models.GetUserByID(uh.DB, uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
@@ -61,14 +81,33 @@ func (uh *UserHandler) GetUserByID(c *gin.Context) {
 }
 
 
+var userListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":       {Column: "id", Kind: listing.Int},
+		"username": {Column: "username", Kind: listing.String},
+		"email":    {Column: "email", Kind: listing.String},
+	},
+	Filters: []listing.Filter{
+		{Param: "username", Column: "username", Kind: listing.String},
+		{Param: "email", Column: "email", Kind: listing.String},
+		{Param: "role_id", Kind: listing.Int, Op: listing.In,
+			Where: "EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = users.id AND ur.role_id IN ?)"},
+	},
+}
+
 func (uh *UserHandler) GetUsers(c *gin.Context) {
-	users, err := models.GetUsers(uh.DB)
+	query, ok := listQuery(c, userListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.User](uh.DB, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching users"})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, page)
 }
 
 
@@ -80,12 +119,19 @@ func (uh *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var updatedUser models.User
-	if err := c.ShouldBindJSON(&updatedUser); err != nil {
+	if allowed, err := canAccess(c, uh.DB, uint(id), models.PermUserManage); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+
+	var input userRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
 		return
 	}
 
+	updatedUser := models.User{Username: input.Username, Email: input.Email, Password: input.Password}
+
 	// Пайдаланушыны жаңарту
 	user, err := models.UpdateUser(uh.DB, uint(id), &updatedUser)
 	if err != nil {
@@ -105,6 +151,11 @@ func (uh *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	if allowed, err := canAccess(c, uh.DB, uint(id), models.PermUserManage); err != nil || !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"message": "User not found"})
+		return
+	}
+
 	err = models.DeleteUser(uh.DB, uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error deleting user"})
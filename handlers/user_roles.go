@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"NomadShop/listing"
 	"NomadShop/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,15 +18,29 @@ func NewUserRoleHandler(db *gorm.DB) *UserRoleHandler {
 	return &UserRoleHandler{DB: db}
 }
 
+var userRoleListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id": {Column: "id", Kind: listing.Int},
+	},
+	Filters: []listing.Filter{
+		{Param: "user_id", Column: "user_id", Kind: listing.Int, Op: listing.In},
+		{Param: "role_id", Column: "role_id", Kind: listing.Int, Op: listing.In},
+	},
+}
+
 func (h *UserRoleHandler) GetAllUserRoles(c *gin.Context) {
-	var userRoles []models.UserRole
-	err := h.DB.Preload("User").Preload("Role").Find(&userRoles).Error
+	query, ok := listQuery(c, userRoleListing)
+	if !ok {
+		return
+	}
+
+	page, err := listing.Find[models.UserRole](h.DB, query, preload("User", "Role"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching all user roles"})
 		return
 	}
 
-	c.JSON(http.StatusOK, userRoles)
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *UserRoleHandler) AddUserRole(c *gin.Context) {
@@ -115,16 +130,20 @@ func (h *UserRoleHandler) GetUserRolesByRole(c *gin.Context) {
 		return
 	}
 
+	query, ok := listQuery(c, userRoleListing)
+	if !ok {
+		return
+	}
+
 	// Рөлге байланысты пайдаланушылардың рөлдерін алу
-	var userRoles []models.UserRole
-	err = h.DB.Where("role_id = ?", uint(roleID)).Preload("User").Preload("Role").Find(&userRoles).Error
+	page, err := listing.Find[models.UserRole](h.DB.Where("role_id = ?", uint(roleID)), query, preload("User", "Role"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Error fetching user roles for the given role"})
 		return
 	}
 
 	// Рөлдер бойынша пайдаланушыларды қайтару
-	c.JSON(http.StatusOK, userRoles)
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *UserRoleHandler) DeleteUserRole(c *gin.Context) {
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"NomadShop/listing"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+)
+
+type WarehouseHandler struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseHandler(db *gorm.DB) *WarehouseHandler {
+	return &WarehouseHandler{DB: db}
+}
+
+var warehouseListing = listing.Spec{
+	Sorts: map[string]listing.Field{
+		"id":       {Column: "id", Kind: listing.Int},
+		"name":     {Column: "name", Kind: listing.String},
+		"priority": {Column: "priority", Kind: listing.Int},
+	},
+	DefaultSort: "priority",
+	Filters: []listing.Filter{
+		{Param: "city", Column: "city", Kind: listing.String, Op: listing.In},
+		{Param: "active", Column: "active", Kind: listing.Bool},
+	},
+}
+
+func (h *WarehouseHandler) GetAllWarehouses(c *gin.Context) {
+	query, ok := listQuery(c, warehouseListing)
+	if !ok {
+		return
+	}
+
+	warehouses, err := listing.Find[models.Warehouse](h.DB, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get warehouses"})
+		return
+	}
+	c.JSON(http.StatusOK, warehouses)
+}
+
+func (h *WarehouseHandler) GetWarehouseByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid warehouse ID"})
+		return
+	}
+
+	warehouse, err := models.GetWarehouseByID(h.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Warehouse not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+func (h *WarehouseHandler) CreateWarehouse(c *gin.Context) {
+	var warehouse models.Warehouse
+	if err := c.ShouldBindJSON(&warehouse); err != nil || warehouse.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if _, err := models.CreateWarehouse(h.DB, &warehouse); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusOK, warehouse)
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid warehouse ID"})
+		return
+	}
+
+	var warehouse models.Warehouse
+	if err := c.ShouldBindJSON(&warehouse); err != nil || warehouse.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		return
+	}
+
+	if _, err := models.GetWarehouseByID(h.DB, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Warehouse not found"})
+		return
+	}
+
+	updatedWarehouse, err := models.UpdateWarehouse(h.DB, uint(id), &warehouse)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update warehouse"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedWarehouse)
+}
+
+// GetProductStock өнім нұсқаларының әр қоймадағы қалдығын қайтарады
+func (h *WarehouseHandler) GetProductStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid product ID"})
+		return
+	}
+
+	product, err := models.GetProductByID(h.DB, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Product not found"})
+		return
+	}
+
+	stocks, err := models.GetWarehouseStocks(h.DB, product.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to get warehouse stock"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"product_id": product.ID, "available": product.Stock, "variants": product.Variants, "warehouses": stocks})
+}
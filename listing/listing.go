@@ -0,0 +1,375 @@
+// Package listing тізім эндпоинттері үшін ортақ сұрау қабаты: limit/cursor бойынша
+// беттеу, sort=price,-id сұрыптауы және типтелген сүзгілер.
+package listing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	String
+	Bool
+	Time
+	Money // Spec.Currency валютасындағы ондық сома ("1250.50"), ең кіші бірліктерге айналдырылады
+)
+
+type Op int
+
+const (
+	Eq Op = iota
+	In    // үтірмен бөлінген мәндер тізімі
+	Gte
+	Lte
+)
+
+// Field сұрыптауға болатын баған. Column кестемен бірге жазылуы мүмкін (products.price).
+type Field struct {
+	Column string
+	Kind   Kind
+}
+
+// Filter сұрау параметрін SQL шартына айналдырады.
+// Where берілсе, бір ? орнына мән (In үшін мәндер тізімі) қойылады.
+type Filter struct {
+	Param  string
+	Column string
+	Kind   Kind
+	Op     Op
+	Where  string
+}
+
+// Spec бір эндпоинттің рұқсат етілген сұрыптаулары мен сүзгілері.
+// Sorts ішінде міндетті түрде "id" болуы керек: ол тең мәндерді ажырату үшін қолданылады.
+// Currency Money түріндегі өрістер бар болса ғана керек.
+type Spec struct {
+	Sorts       map[string]Field
+	DefaultSort string
+	Filters     []Filter
+	Currency    string
+}
+
+type sortField struct {
+	name  string
+	field Field
+	desc  bool
+}
+
+type condition struct {
+	param string
+	sql   string
+	args  []interface{}
+}
+
+// Query талданған сұрау параметрлері
+type Query struct {
+	Limit      int
+	currency   string
+	sort       []sortField
+	sortKey    string
+	conditions []condition
+	after      []interface{}
+}
+
+// Page барлық тізім эндпоинттерінің ортақ жауабы. NextCursor келесі бет болмаса null.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int64   `json:"total"`
+}
+
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// Parse limit, cursor, sort және spec-те сипатталған сүзгілерді оқиды.
+// Қайтарылған қателер клиенттің қатесі ретінде көрсетілуі керек.
+func Parse(values url.Values, spec Spec) (*Query, error) {
+	query := &Query{Limit: DefaultLimit, currency: spec.Currency}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return nil, errors.New("limit must be a positive integer")
+		}
+		query.Limit = min(limit, MaxLimit)
+	}
+
+	if err := query.parseSort(values.Get("sort"), spec); err != nil {
+		return nil, err
+	}
+
+	for _, filter := range spec.Filters {
+		raw := strings.TrimSpace(values.Get(filter.Param))
+		if raw == "" {
+			continue
+		}
+		cond, err := filter.condition(raw, spec.Currency)
+		if err != nil {
+			return nil, err
+		}
+		cond.param = filter.Param
+		query.conditions = append(query.conditions, cond)
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		if err := query.parseCursor(raw); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+func (q *Query) parseSort(raw string, spec Spec) error {
+	if raw == "" {
+		raw = spec.DefaultSort
+	}
+	if raw == "" {
+		raw = "id"
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		name, desc := strings.TrimPrefix(part, "-"), strings.HasPrefix(part, "-")
+		field, ok := spec.Sorts[name]
+		if !ok {
+			return fmt.Errorf("cannot sort by %q", name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		q.sort = append(q.sort, sortField{name: name, field: field, desc: desc})
+		names = append(names, part)
+	}
+
+	// Cursor бір мәнді болуы үшін соңында әрқашан id тұрады
+	if !seen["id"] {
+		field, ok := spec.Sorts["id"]
+		if !ok {
+			return errors.New("listing: spec has no id sort")
+		}
+		q.sort = append(q.sort, sortField{name: "id", field: field})
+		names = append(names, "id")
+	}
+	q.sortKey = strings.Join(names, ",")
+	return nil
+}
+
+func (q *Query) parseCursor(raw string) error {
+	invalid := errors.New("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) != len(q.sort) {
+		return invalid
+	}
+	if c.Sort != q.sortKey {
+		return errors.New("cursor does not match sort")
+	}
+
+	for i, value := range c.Values {
+		parsed, err := parseValue(q.sort[i].field.Kind, value, q.currency)
+		if err != nil {
+			return invalid
+		}
+		q.after = append(q.after, parsed)
+	}
+	return nil
+}
+
+func (f Filter) condition(raw, currency string) (condition, error) {
+	var arg interface{}
+	if f.Op == In {
+		var list []interface{}
+		for _, part := range strings.Split(raw, ",") {
+			value, err := parseValue(f.Kind, strings.TrimSpace(part), currency)
+			if err != nil {
+				return condition{}, fmt.Errorf("invalid value for %s", f.Param)
+			}
+			list = append(list, value)
+		}
+		arg = list
+	} else {
+		value, err := parseValue(f.Kind, raw, currency)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid value for %s", f.Param)
+		}
+		arg = value
+	}
+
+	if f.Where != "" {
+		return condition{sql: f.Where, args: []interface{}{arg}}, nil
+	}
+
+	switch f.Op {
+	case In:
+		return condition{sql: f.Column + " IN ?", args: []interface{}{arg}}, nil
+	case Gte:
+		return condition{sql: f.Column + " >= ?", args: []interface{}{arg}}, nil
+	case Lte:
+		// Тек күн берілсе, сол күн толығымен қамтылады
+		if t, ok := arg.(time.Time); ok && len(raw) == len(time.DateOnly) {
+			return condition{sql: f.Column + " < ?", args: []interface{}{t.AddDate(0, 0, 1)}}, nil
+		}
+		return condition{sql: f.Column + " <= ?", args: []interface{}{arg}}, nil
+	default:
+		return condition{sql: f.Column + " = ?", args: []interface{}{arg}}, nil
+	}
+}
+
+// Filters берілген параметрлерден басқа барлық сүзгілерді қолданатын scope қайтарады.
+// Мысалы, facet санағанда әр facet өз сүзгісін есепке алмайды.
+func (q *Query) Filters(except ...string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, cond := range q.conditions {
+			if !slices.Contains(except, cond.param) {
+				db = db.Where(cond.sql, cond.args...)
+			}
+		}
+		return db
+	}
+}
+
+// Find сүзгілерді, cursor-ды және сұрыптауды қолданып, бір бетті оқиды.
+// scopes тек беттің өзіне қолданылады (мысалы, Preload), Total санауға емес.
+func Find[T any](db *gorm.DB, query *Query, scopes ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	filtered := query.Filters()(db.Model(new(T)))
+
+	page := &Page[T]{Items: []T{}}
+	if err := filtered.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	tx := filtered.Session(&gorm.Session{}).Scopes(scopes...)
+	if query.after != nil {
+		sql, args := query.keyset()
+		tx = tx.Where(sql, args...)
+	}
+	for _, sort := range query.sort {
+		tx = tx.Order(clause.OrderByColumn{Column: clause.Column{Name: sort.field.Column, Raw: true}, Desc: sort.desc})
+	}
+	if err := tx.Limit(query.Limit + 1).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		next, err := query.encodeCursor(tx, &page.Items[len(page.Items)-1])
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+// keyset соңғы жазбадан кейінгі жолдарды таңдайтын шарт құрастырады:
+// (a > x) OR (a = x AND b < y) OR ...
+func (q *Query) keyset() (string, []interface{}) {
+	var ors []string
+	var args []interface{}
+	for i, sort := range q.sort {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, q.sort[j].field.Column+" = ?")
+			args = append(args, q.after[j])
+		}
+		op := " > ?"
+		if sort.desc {
+			op = " < ?"
+		}
+		ands = append(ands, sort.field.Column+op)
+		args = append(args, q.after[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func (q *Query) encodeCursor(tx *gorm.DB, item interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(item); err != nil {
+		return "", err
+	}
+
+	c := cursor{Sort: q.sortKey}
+	value := reflect.ValueOf(item).Elem()
+	for _, sort := range q.sort {
+		column := sort.field.Column
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			column = column[i+1:]
+		}
+		field := stmt.Schema.LookUpField(column)
+		if field == nil {
+			return "", fmt.Errorf("listing: %s has no field %s", stmt.Schema.Name, column)
+		}
+		fieldValue, _ := field.ValueOf(tx.Statement.Context, value)
+		c.Values = append(c.Values, formatValue(sort.field.Kind, fieldValue))
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func parseValue(kind Kind, raw, currency string) (interface{}, error) {
+	switch kind {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Float:
+		return strconv.ParseFloat(raw, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Time:
+		if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.DateOnly, raw)
+	case Money:
+		amount, err := money.Parse(raw, currency)
+		return amount.Amount, err
+	default:
+		return raw, nil
+	}
+}
+
+func formatValue(kind Kind, value interface{}) string {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && !v.IsNil() {
+		value = v.Elem().Interface()
+	}
+	if kind == Time {
+		if t, ok := value.(time.Time); ok {
+			return t.Format(time.RFC3339Nano)
+		}
+	}
+	return fmt.Sprint(value)
+}
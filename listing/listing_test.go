@@ -0,0 +1,275 @@
+package listing
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	ID        uint
+	Name      string
+	Price     int64
+	CreatedAt time.Time
+}
+
+var testSpec = Spec{
+	Sorts: map[string]Field{
+		"id":         {Column: "id", Kind: Int},
+		"name":       {Column: "items.name", Kind: String},
+		"price":      {Column: "price", Kind: Int},
+		"created_at": {Column: "created_at", Kind: Time},
+	},
+	DefaultSort: "-created_at",
+	Filters: []Filter{
+		{Param: "name", Column: "name", Kind: String, Op: In},
+		{Param: "price_min", Column: "price", Kind: Money, Op: Gte},
+		{Param: "in_stock", Column: "stock", Kind: Bool, Where: "(stock > 0) = ?"},
+		{Param: "date_to", Column: "created_at", Kind: Time, Op: Lte},
+		{Param: "id", Column: "id", Kind: Int},
+	},
+	Currency: "KZT",
+}
+
+// dryRunDB базаға қосылмайды: encodeCursor тек схеманы талдау үшін қолданады
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := sql.Open("pgx", "host=127.0.0.1 port=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		sortKey   string
+		condition []condition
+		err       string
+	}{
+		{name: "defaults", query: "", limit: DefaultLimit, sortKey: "-created_at,id"},
+		{name: "limit capped", query: "limit=1000", limit: MaxLimit, sortKey: "-created_at,id"},
+		{name: "zero limit", query: "limit=0", err: "limit must be a positive integer"},
+		{name: "bad limit", query: "limit=ten", err: "limit must be a positive integer"},
+		{name: "explicit id kept in place", query: "sort=-id,price", limit: DefaultLimit, sortKey: "-id,price"},
+		{name: "duplicate sort ignored", query: "sort=price,-price", limit: DefaultLimit, sortKey: "price,id"},
+		{name: "unknown sort", query: "sort=stock", err: `cannot sort by "stock"`},
+		{
+			name: "in filter", query: "name=a,%20b", limit: DefaultLimit, sortKey: "-created_at,id",
+			condition: []condition{{param: "name", sql: "name IN ?", args: []interface{}{[]interface{}{"a", "b"}}}},
+		},
+		{
+			name: "money filter in minor units", query: "price_min=12.50", limit: DefaultLimit, sortKey: "-created_at,id",
+			condition: []condition{{param: "price_min", sql: "price >= ?", args: []interface{}{int64(1250)}}},
+		},
+		{
+			name: "custom where", query: "in_stock=true", limit: DefaultLimit, sortKey: "-created_at,id",
+			condition: []condition{{param: "in_stock", sql: "(stock > 0) = ?", args: []interface{}{true}}},
+		},
+		{
+			name: "date only upper bound covers whole day", query: "date_to=2024-03-01", limit: DefaultLimit, sortKey: "-created_at,id",
+			condition: []condition{{param: "date_to", sql: "created_at < ?", args: []interface{}{time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}}},
+		},
+		{
+			name: "timestamp upper bound", query: "date_to=2024-03-01T10:00:00Z", limit: DefaultLimit, sortKey: "-created_at,id",
+			condition: []condition{{param: "date_to", sql: "created_at <= ?", args: []interface{}{time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}}},
+		},
+		{name: "bad int filter", query: "id=abc", err: "invalid value for id"},
+		{name: "bad money filter", query: "price_min=1.234", err: "invalid value for price_min"},
+		{name: "garbage cursor", query: "cursor=%25%25", err: "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, err := Parse(values, testSpec)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Parse(%q) error = %v, want %q", tt.query, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.query, err)
+			}
+			if query.Limit != tt.limit {
+				t.Errorf("Limit = %d, want %d", query.Limit, tt.limit)
+			}
+			if query.sortKey != tt.sortKey {
+				t.Errorf("sortKey = %q, want %q", query.sortKey, tt.sortKey)
+			}
+			if !reflect.DeepEqual(query.conditions, tt.condition) {
+				t.Errorf("conditions = %#v, want %#v", query.conditions, tt.condition)
+			}
+		})
+	}
+}
+
+func TestParseRequiresIDSort(t *testing.T) {
+	spec := Spec{Sorts: map[string]Field{"name": {Column: "name", Kind: String}}}
+	if _, err := Parse(url.Values{"sort": {"name"}}, spec); err == nil {
+		t.Fatal("Parse accepted a spec without id sort")
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name  string
+		sort  string
+		after []interface{}
+		sql   string
+	}{
+		{
+			name:  "id only",
+			sort:  "id",
+			after: []interface{}{int64(7)},
+			sql:   "((id > ?))",
+		},
+		{
+			name:  "descending with tie breaker",
+			sort:  "-price",
+			after: []interface{}{int64(500), int64(7)},
+			sql:   "((price < ?) OR (price = ? AND id > ?))",
+		},
+		{
+			name:  "three columns",
+			sort:  "name,-created_at",
+			after: []interface{}{"b", "t", int64(3)},
+			sql:   "((items.name > ?) OR (items.name = ? AND created_at < ?) OR (items.name = ? AND created_at = ? AND id > ?))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := Parse(url.Values{"sort": {tt.sort}}, testSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query.after = tt.after
+
+			sql, args := query.keyset()
+			if sql != tt.sql {
+				t.Errorf("keyset() sql = %q, want %q", sql, tt.sql)
+			}
+			// Әр OR тармағы алдыңғы бағандардың мәндерін қайталайды
+			var want []interface{}
+			for i := range tt.after {
+				want = append(want, tt.after[:i+1]...)
+			}
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("keyset() args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	db := dryRunDB(t)
+	created := time.Date(2024, 3, 1, 10, 30, 0, 123456789, time.UTC)
+	last := &item{ID: 42, Name: "Kettle", Price: 1250, CreatedAt: created}
+
+	tests := []struct {
+		sort  string
+		after []interface{}
+	}{
+		{sort: "", after: []interface{}{created, int64(42)}},
+		{sort: "price,-id", after: []interface{}{int64(1250), int64(42)}},
+		{sort: "name", after: []interface{}{"Kettle", int64(42)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			query, err := Parse(url.Values{"sort": {tt.sort}}, testSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next, err := query.encodeCursor(db, last)
+			if err != nil {
+				t.Fatalf("encodeCursor() error = %v", err)
+			}
+
+			resumed, err := Parse(url.Values{"sort": {tt.sort}, "cursor": {next}}, testSpec)
+			if err != nil {
+				t.Fatalf("Parse(cursor) error = %v", err)
+			}
+			if !reflect.DeepEqual(resumed.after, tt.after) {
+				t.Errorf("after = %#v, want %#v", resumed.after, tt.after)
+			}
+		})
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	db := dryRunDB(t)
+	query, err := Parse(url.Values{"sort": {"price"}}, testSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := query.encodeCursor(db, &item{ID: 1, Price: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+		err    string
+	}{
+		{name: "other sort", sort: "-price", cursor: next, err: "cursor does not match sort"},
+		{name: "not base64", sort: "price", cursor: "***", err: "invalid cursor"},
+		{name: "not json", sort: "price", cursor: encode("price"), err: "invalid cursor"},
+		{name: "wrong value count", sort: "price", cursor: encode(`{"s":"price,id","v":["1"]}`), err: "invalid cursor"},
+		{name: "wrong value type", sort: "price", cursor: encode(`{"s":"price,id","v":["cheap","1"]}`), err: "invalid cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(url.Values{"sort": {tt.sort}, "cursor": {tt.cursor}}, testSpec)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("Parse() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, time.FixedZone("ALMT", 5*3600))
+	price := int64(99)
+	tests := []struct {
+		kind  Kind
+		value interface{}
+		want  string
+	}{
+		{Int, uint(5), "5"},
+		{Int, &price, "99"},
+		{String, "a,b", "a,b"},
+		{Bool, true, "true"},
+		{Time, at, "2024-01-02T03:04:05.000000006+05:00"},
+		{Time, &at, "2024-01-02T03:04:05.000000006+05:00"},
+	}
+	for _, tt := range tests {
+		if got := formatValue(tt.kind, tt.value); got != tt.want {
+			t.Errorf("formatValue(%v, %v) = %q, want %q", tt.kind, tt.value, got, tt.want)
+		}
+	}
+}
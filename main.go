@@ -1,64 +1,317 @@
 package main
 
 import (
+	"NomadShop/auth"
+	"NomadShop/config"
 	"NomadShop/handlers"
+	"NomadShop/jobs"
+	"NomadShop/media"
+	"NomadShop/middleware"
 	"NomadShop/models"
-	"fmt"
+	"NomadShop/notify"
+	"NomadShop/storage"
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
+	"os"
+	"strings"
 )
 
 var db *gorm.DB
 var err error
 
-func setupDatabase() *gorm.DB {
-	dsn := "user=postgres password=asd12345 dbname=nomadshop port=5432 sslmode=disable"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func setupDatabase(cfg config.DatabaseConfig) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(cfg.DSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Could not connect to the database:", err)
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Role{}, &models.UserRole{}, &models.Product{}, &models.Category{},
-		&models.CartItem{}, &models.FavoriteItem{}, &models.Order{}, &models.OrderItem{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Error during migration:", err)
+		log.Fatal("Could not configure the database pool:", err)
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+
+	return db
+}
 
-	if err := resetAutoIncrement(db, "products"); err != nil {
-		log.Println("Error resetting auto increment for products:", err)
+func setupNotifier(cfg config.NotificationsConfig) notify.Notifier {
+	if cfg.Driver == "file" {
+		notifier, err := notify.NewFile(cfg.FilePath)
+		if err != nil {
+			log.Fatal("Could not set up notifications:", err)
+		}
+		return notifier
 	}
-	if err := resetAutoIncrement(db, "cart_items"); err != nil {
-		log.Println("Error resetting auto increment for cart_items:", err)
+	return notify.Log{}
+}
+
+func setupStorage(cfg config.StorageConfig) storage.Storage {
+	store, err := storage.NewLocal(cfg.LocalDir, cfg.BaseURL)
+	if err != nil {
+		log.Fatal("Could not set up file storage:", err)
 	}
+	return store
+}
 
-	return db
+// Қорғалған маршруттар және оларға қажетті рұқсаттар
+var accessRules = []middleware.AccessRule{
+	{Method: "GET", Path: "/products_all", Permission: models.PermProductWrite},
+	{Method: "POST", Path: "/products/create", Permission: models.PermProductWrite},
+	{Method: "PUT", Path: "/products/:id", Permission: models.PermProductWrite},
+	{Method: "DELETE", Path: "/products/:id", Permission: models.PermProductWrite},
+	{Method: "GET", Path: "/products/:id/stock_movements", Permission: models.PermInventoryWrite},
+	{Method: "POST", Path: "/products/:id/stock_movements", Permission: models.PermInventoryWrite},
+	{Method: "GET", Path: "/products/:id/stock", Permission: models.PermInventoryWrite},
+	{Method: "POST", Path: "/products/:id/variants", Permission: models.PermProductWrite},
+	{Method: "POST", Path: "/products/:id/variants/matrix", Permission: models.PermProductWrite},
+	{Method: "PUT", Path: "/products/:id/variants/:variant_id", Permission: models.PermProductWrite},
+	{Method: "DELETE", Path: "/products/:id/variants/:variant_id", Permission: models.PermProductWrite},
+	{Method: "POST", Path: "/products/:id/images", Permission: models.PermProductWrite},
+	{Method: "PUT", Path: "/products/:id/images/:image_id", Permission: models.PermProductWrite},
+	{Method: "DELETE", Path: "/products/:id/images/:image_id", Permission: models.PermProductWrite},
+
+	{Method: "GET", Path: "/warehouses", Permission: models.PermInventoryWrite},
+	{Method: "GET", Path: "/warehouses/:id", Permission: models.PermInventoryWrite},
+	{Method: "POST", Path: "/warehouses", Permission: models.PermInventoryWrite},
+	{Method: "PUT", Path: "/warehouses/:id", Permission: models.PermInventoryWrite},
+
+	{Method: "POST", Path: "/categories", Permission: models.PermCategoryWrite},
+	{Method: "PUT", Path: "/categories/:id", Permission: models.PermCategoryWrite},
+	{Method: "DELETE", Path: "/categories/:id", Permission: models.PermCategoryWrite},
+	{Method: "POST", Path: "/categories/:id/move", Permission: models.PermCategoryWrite},
+
+	{Method: "PUT", Path: "/exchange_rates/:currency", Permission: models.PermCurrencyManage},
+	{Method: "DELETE", Path: "/exchange_rates/:currency", Permission: models.PermCurrencyManage},
+	{Method: "POST", Path: "/exchange_rates/import", Permission: models.PermCurrencyManage},
+
+	{Method: "GET", Path: "/coupons", Permission: models.PermCouponManage},
+	{Method: "GET", Path: "/coupons/:id", Permission: models.PermCouponManage},
+	{Method: "POST", Path: "/coupons", Permission: models.PermCouponManage},
+	{Method: "PUT", Path: "/coupons/:id", Permission: models.PermCouponManage},
+	{Method: "DELETE", Path: "/coupons/:id", Permission: models.PermCouponManage},
+	{Method: "GET", Path: "/coupons/:id/redemptions", Permission: models.PermCouponManage},
+
+	{Method: "GET", Path: "/promotions", Permission: models.PermPromotionManage},
+	{Method: "GET", Path: "/promotions/:id", Permission: models.PermPromotionManage},
+	{Method: "POST", Path: "/promotions", Permission: models.PermPromotionManage},
+	{Method: "PUT", Path: "/promotions/:id", Permission: models.PermPromotionManage},
+	{Method: "DELETE", Path: "/promotions/:id", Permission: models.PermPromotionManage},
+
+	{Method: "GET", Path: "/users", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/users/:id"},
+	{Method: "PUT", Path: "/users/:id"},
+	{Method: "DELETE", Path: "/users/:id"},
+
+	{Method: "GET", Path: "/roles", Permission: models.PermRoleManage},
+	{Method: "GET", Path: "/roles/:id", Permission: models.PermRoleManage},
+	{Method: "POST", Path: "/roles", Permission: models.PermRoleManage},
+	{Method: "PUT", Path: "/roles/:id", Permission: models.PermRoleManage},
+	{Method: "DELETE", Path: "/roles/:id", Permission: models.PermRoleManage},
+	{Method: "POST", Path: "/roles/:id/permissions", Permission: models.PermRoleManage},
+	{Method: "DELETE", Path: "/roles/:id/permissions/:permission_id", Permission: models.PermRoleManage},
+
+	{Method: "GET", Path: "/permissions", Permission: models.PermRoleManage},
+	{Method: "GET", Path: "/permissions/:id", Permission: models.PermRoleManage},
+	{Method: "POST", Path: "/permissions", Permission: models.PermRoleManage},
+	{Method: "PUT", Path: "/permissions/:id", Permission: models.PermRoleManage},
+	{Method: "DELETE", Path: "/permissions/:id", Permission: models.PermRoleManage},
+
+	{Method: "GET", Path: "/user_roles/all", Permission: models.PermRoleManage},
+	{Method: "POST", Path: "/user_roles", Permission: models.PermRoleManage},
+	{Method: "GET", Path: "/user_roles/", Permission: models.PermRoleManage},
+	{Method: "GET", Path: "/user-roles", Permission: models.PermRoleManage},
+	{Method: "DELETE", Path: "/user_roles/:user_id/:role_id", Permission: models.PermRoleManage},
+
+	// Пайдаланушының өз деректері: тек кірген пайдаланушыларға
+	{Method: "GET", Path: "/cart_items/:user_id"},
+	{Method: "POST", Path: "/cart_items"},
+	{Method: "GET", Path: "/cart_items"},
+	{Method: "PUT", Path: "/cart_items/:id"},
+	{Method: "DELETE", Path: "/cart_items/:id"},
+	{Method: "GET", Path: "/favorite_items/:id"},
+	{Method: "GET", Path: "/favorite-items"},
+	{Method: "POST", Path: "/favorite_items"},
+	{Method: "DELETE", Path: "/favorite_items/:id"},
+	{Method: "POST", Path: "/orders"},
+	{Method: "POST", Path: "/checkout"},
+	{Method: "POST", Path: "/cart/coupon"},
+	{Method: "GET", Path: "/cart/summary"},
+	{Method: "PUT", Path: "/cart/reminders"},
+	{Method: "GET", Path: "/orders/"},
+	{Method: "GET", Path: "/orders/by_id/"},
+	{Method: "POST", Path: "/orders/:order_id/transitions"},
+	{Method: "GET", Path: "/orders/:order_id/history"},
+	{Method: "GET", Path: "/order_items"},
+
+	{Method: "GET", Path: "/cart_items_all", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/abandoned_carts", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/cart-items", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/favorite_items_all", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/favorite_items", Permission: models.PermUserRead},
+	{Method: "GET", Path: "/orders/all", Permission: models.PermOrderRead},
+	{Method: "PUT", Path: "/orders/:order_id", Permission: models.PermOrderWrite},
+	{Method: "DELETE", Path: "/orders/:order_id", Permission: models.PermOrderWrite},
+	{Method: "GET", Path: "/order_items_all", Permission: models.PermOrderRead},
+	{Method: "GET", Path: "/order_items/by_product_id/", Permission: models.PermOrderRead},
+	{Method: "POST", Path: "/order_items", Permission: models.PermOrderWrite},
+	{Method: "PUT", Path: "/order_items/:id", Permission: models.PermOrderWrite},
+	{Method: "DELETE", Path: "/order_items/:id", Permission: models.PermOrderWrite},
+}
+
+func passwordParams(cfg config.AuthConfig) auth.PasswordParams {
+	params := auth.DefaultPasswordParams
+	params.Algorithm = cfg.PasswordAlgorithm
+	params.BcryptCost = cfg.BcryptCost
+	return params
 }
 
-// Автоинкрементті 1-ден бастап орнату
-func resetAutoIncrement(db *gorm.DB, tableName string) error {
-	query := fmt.Sprintf("ALTER SEQUENCE %s_id_seq RESTART WITH 1;", tableName)
-	return db.Exec(query).Error
+// shopSettings модель функцияларына берілетін баптаулар
+func shopSettings(cfg *config.Config) models.Settings {
+	return models.Settings{
+		Currency:       cfg.Currency.Default,
+		Rounding:       cfg.Currency.RoundingRule(),
+		ShippingRate:   cfg.ShippingRate(),
+		TaxRate:        cfg.TaxRate(),
+		TaxIncluded:    cfg.Tax.Included,
+		ReservationTTL: cfg.Inventory.ReservationTTL.Duration,
+		GuestCartTTL:   cfg.Cart.GuestTTL.Duration,
+	}
 }
 
 func main() {
-	db = setupDatabase()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
 
+	err = auth.SetPasswordParams(passwordParams(cfg.Auth))
+	if err != nil {
+		log.Fatal("Invalid password hashing configuration:", err)
+	}
+
+	db = setupDatabase(cfg.Database)
+	settings := shopSettings(cfg)
+
+	if len(os.Args) > 1 {
+		runCommand(db, os.Args[1:])
+		return
+	}
+
+	// Бірнеше реплика қатар іске қосылса, миграцияны тек біреуі орындайды
+	if err := migrateUp(db); err != nil {
+		log.Fatal("Error during migration:", err)
+	}
+
+	// Мерзімі өткен резервтерді босату
+	jobs.Every(context.Background(), "release-expired-reservations", cfg.Inventory.SweepInterval.Duration,
+		func(ctx context.Context) error {
+			cancelled, err := models.ReleaseExpiredReservations(db.WithContext(ctx))
+			if cancelled > 0 {
+				log.Printf("Cancelled %d orders with expired stock reservations", cancelled)
+			}
+			return err
+		})
+
+	// Ұзақ қолданылмаған қонақ себеттерін жою
+	jobs.Every(context.Background(), "expire-guest-carts", cfg.Cart.SweepInterval.Duration,
+		func(ctx context.Context) error {
+			expired, err := models.ExpireGuestCarts(db.WithContext(ctx), settings.GuestCartTTL)
+			if expired > 0 {
+				log.Printf("Deleted %d expired guest carts", expired)
+			}
+			return err
+		})
+
+	// Тасталған себеттерді тіркеу және еске салу жіберу
+	notifier := setupNotifier(cfg.Notifications)
+	jobs.Every(context.Background(), "abandoned-cart-reminders", cfg.Cart.ReminderInterval.Duration,
+		func(ctx context.Context) error {
+			// Кейбір себеттерді тіркеу сәтсіз болса да, бұрынғы оқиғалар жіберіледі
+			detected, detectErr := models.DetectAbandonedCarts(db.WithContext(ctx), settings, cfg.Cart.AbandonedAfter.Duration)
+			if detected > 0 {
+				log.Printf("Detected %d abandoned carts", detected)
+			}
+			sent, err := models.SendCartReminders(ctx, db.WithContext(ctx), notifier)
+			if sent > 0 {
+				log.Printf("Sent %d abandoned cart reminders", sent)
+			}
+			return errors.Join(detectErr, err)
+		})
+
+	// Жүктелген суреттердің кішірейтілген нұсқаларын жасау
+	store := setupStorage(cfg.Storage)
+	jobs.Every(context.Background(), "process-product-images", cfg.Images.ProcessInterval.Duration,
+		func(ctx context.Context) error {
+			return media.ProcessPendingImages(ctx, db.WithContext(ctx), store)
+		})
+
+	tokens := auth.NewTokenIssuer(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL.Duration, cfg.Auth.RefreshTokenTTL.Duration)
+
+	gin.SetMode(cfg.Server.GinMode)
 	r := gin.Default()
+	r.Use(middleware.CORS(cfg.Server.CORSOrigins), middleware.Authenticate(tokens), middleware.Authorize(db, accessRules))
+
+	authHandler := handlers.NewAuthHandler(db, tokens, settings)
+	r.POST("/auth/login", authHandler.Login)
+	r.POST("/auth/refresh", authHandler.Refresh)
+	r.POST("/auth/logout", authHandler.Logout)
 
-	handler := handlers.Handler{DB: db}
+	handler := handlers.Handler{DB: db, Storage: store, Settings: settings}
 	r.GET("/products_all", handler.GetProducts)
+	r.GET("/products/search", handler.SearchProducts)
 	r.GET("/products/:id", handler.GetProductByID)
 	r.GET("/products", handler.GetProductsByCategory)
 	r.POST("/products/create", handler.CreateProduct)
 	r.PUT("/products/:id", handler.UpdateProduct)
 	r.DELETE("/products/:id", handler.DeleteProduct)
+	r.GET("/products/:id/stock_movements", handler.GetStockMovements)
+	r.POST("/products/:id/stock_movements", handler.CreateStockMovement)
+
+	variantHandler := handlers.NewProductVariantHandler(db, settings)
+	r.GET("/products/:id/variants", variantHandler.GetVariants)
+	r.POST("/products/:id/variants", variantHandler.CreateVariant)
+	r.POST("/products/:id/variants/matrix", variantHandler.GenerateMatrix)
+	r.PUT("/products/:id/variants/:variant_id", variantHandler.UpdateVariant)
+	r.DELETE("/products/:id/variants/:variant_id", variantHandler.DeleteVariant)
+
+	imageHandler := handlers.NewProductImageHandler(db, store, int64(cfg.Images.MaxUploadSize), cfg.Images.MaxPixels)
+	r.GET("/products/:id/images", imageHandler.GetImages)
+	r.POST("/products/:id/images", imageHandler.UploadImage)
+	r.PUT("/products/:id/images/:image_id", imageHandler.UpdateImage)
+	r.DELETE("/products/:id/images/:image_id", imageHandler.DeleteImage)
+	// Жергілікті қоймадағы файлдарды қолданбаның өзі береді
+	if strings.HasPrefix(cfg.Storage.BaseURL, "/") {
+		r.Static(cfg.Storage.BaseURL, cfg.Storage.LocalDir)
+	}
+
+	warehouseHandler := handlers.NewWarehouseHandler(db)
+	r.GET("/warehouses", warehouseHandler.GetAllWarehouses)
+	r.GET("/warehouses/:id", warehouseHandler.GetWarehouseByID)
+	r.POST("/warehouses", warehouseHandler.CreateWarehouse)
+	r.PUT("/warehouses/:id", warehouseHandler.UpdateWarehouse)
+	r.GET("/products/:id/stock", warehouseHandler.GetProductStock)
 
 	categoryHandler := handlers.NewCategoryHandler(db)
 	r.GET("/categories", categoryHandler.GetAllCategories)
 	r.POST("/categories", categoryHandler.CreateCategory)
+	r.GET("/categories/tree", categoryHandler.GetCategoryTree)
 	r.GET("/categories/:id", categoryHandler.GetCategoryByID)
+	r.PUT("/categories/:id", categoryHandler.UpdateCategory)
+	r.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+	r.GET("/categories/:id/breadcrumbs", categoryHandler.GetCategoryBreadcrumbs)
+	r.POST("/categories/:id/move", categoryHandler.MoveCategory)
+
+	exchangeRateHandler := handlers.NewExchangeRateHandler(db, settings)
+	r.GET("/exchange_rates", exchangeRateHandler.GetExchangeRates)
+	r.PUT("/exchange_rates/:currency", exchangeRateHandler.SetExchangeRate)
+	r.DELETE("/exchange_rates/:currency", exchangeRateHandler.DeleteExchangeRate)
+	r.POST("/exchange_rates/import", exchangeRateHandler.ImportExchangeRates)
 
 	userHandler := handlers.NewUserHandler(db)
 	r.POST("/users", userHandler.CreateUser)
@@ -73,6 +326,15 @@ func main() {
 	r.POST("/roles", roleHandler.CreateRole)
 	r.PUT("/roles/:id", roleHandler.UpdateRole)
 	r.DELETE("/roles/:id", roleHandler.DeleteRole)
+	r.POST("/roles/:id/permissions", roleHandler.AddRolePermission)
+	r.DELETE("/roles/:id/permissions/:permission_id", roleHandler.RemoveRolePermission)
+
+	permissionHandler := handlers.NewPermissionHandler(db)
+	r.GET("/permissions", permissionHandler.GetAllPermissions)
+	r.GET("/permissions/:id", permissionHandler.GetPermissionByID)
+	r.POST("/permissions", permissionHandler.CreatePermission)
+	r.PUT("/permissions/:id", permissionHandler.UpdatePermission)
+	r.DELETE("/permissions/:id", permissionHandler.DeletePermission)
 
 	userRoleHandler := handlers.NewUserRoleHandler(db)
 	r.GET("/user_roles/all", userRoleHandler.GetAllUserRoles)
@@ -81,7 +343,7 @@ func main() {
 	r.GET("/user-roles", userRoleHandler.GetUserRolesByRole)
 	r.DELETE("/user_roles/:user_id/:role_id", userRoleHandler.DeleteUserRole)
 
-	cartItemHandler := handlers.NewCartItemHandler(db)
+	cartItemHandler := handlers.NewCartItemHandler(db, settings)
 	r.GET("/cart_items/:user_id", cartItemHandler.GetCartItems)
 	r.POST("/cart_items", cartItemHandler.CreateCartItem)
 	r.GET("/cart_items", cartItemHandler.GetCartItemsByUser)
@@ -89,6 +351,19 @@ func main() {
 	r.PUT("/cart_items/:id", cartItemHandler.UpdateCartItem)
 	r.DELETE("/cart_items/:id", cartItemHandler.DeleteCartItem)
 	r.GET("/cart_items_all", cartItemHandler.GetAllCartItems)
+	r.GET("/cart/summary", cartItemHandler.GetCartSummary)
+	r.PUT("/cart/reminders", cartItemHandler.SetCartReminders)
+
+	abandonedCartHandler := handlers.NewAbandonedCartHandler(db)
+	r.GET("/abandoned_carts", abandonedCartHandler.GetAbandonedCarts)
+
+	// Қонақ себеті: кіруді қажет етпейді, X-Cart-Token немесе cart_token cookie арқылы
+	guestCartHandler := handlers.NewGuestCartHandler(db, settings)
+	r.GET("/guest_cart", guestCartHandler.GetGuestCart)
+	r.POST("/guest_cart/items", guestCartHandler.AddGuestCartItem)
+	r.PUT("/guest_cart/items/:id", guestCartHandler.UpdateGuestCartItem)
+	r.DELETE("/guest_cart/items/:id", guestCartHandler.DeleteGuestCartItem)
+	r.GET("/guest_cart/summary", guestCartHandler.GetGuestCartSummary)
 
 	favoriteItemHandler := handlers.NewFavoriteItemHandler(db)
 	r.GET("/favorite_items_all", favoriteItemHandler.GetAllFavoriteItems)
@@ -98,15 +373,34 @@ func main() {
 	r.POST("/favorite_items", favoriteItemHandler.CreateFavoriteItem)
 	r.DELETE("/favorite_items/:id", favoriteItemHandler.DeleteFavoriteItem)
 
-	orderHandler := handlers.NewOrderHandler(db)
+	orderHandler := handlers.NewOrderHandler(db, settings)
 	r.POST("/orders", orderHandler.CreateOrder)
+	r.POST("/checkout", orderHandler.Checkout)
 	r.GET("/orders/", orderHandler.GetOrdersByUser)
 	r.GET("/orders/by_id/", orderHandler.GetOrderByID)
 	r.GET("/orders/all", orderHandler.GetAllOrders)
 	r.PUT("/orders/:order_id", orderHandler.UpdateOrder)
+	r.POST("/orders/:order_id/transitions", orderHandler.TransitionOrder)
+	r.GET("/orders/:order_id/history", orderHandler.GetOrderHistory)
 	r.DELETE("/orders/:order_id", orderHandler.DeleteOrder)
 
-	orderItemHandler := handlers.NewOrderItemHandler(db)
+	couponHandler := handlers.NewCouponHandler(db, settings)
+	r.GET("/coupons", couponHandler.GetCoupons)
+	r.GET("/coupons/:id", couponHandler.GetCouponByID)
+	r.POST("/coupons", couponHandler.CreateCoupon)
+	r.PUT("/coupons/:id", couponHandler.UpdateCoupon)
+	r.DELETE("/coupons/:id", couponHandler.DeleteCoupon)
+	r.GET("/coupons/:id/redemptions", couponHandler.GetRedemptions)
+	r.POST("/cart/coupon", couponHandler.ApplyToCart)
+
+	promotionHandler := handlers.NewPromotionHandler(db, settings)
+	r.GET("/promotions", promotionHandler.GetPromotions)
+	r.GET("/promotions/:id", promotionHandler.GetPromotionByID)
+	r.POST("/promotions", promotionHandler.CreatePromotion)
+	r.PUT("/promotions/:id", promotionHandler.UpdatePromotion)
+	r.DELETE("/promotions/:id", promotionHandler.DeletePromotion)
+
+	orderItemHandler := handlers.NewOrderItemHandler(db, settings)
 	r.GET("/order_items_all", orderItemHandler.GetAllOrderItems)
 	r.POST("/order_items", orderItemHandler.CreateOrderItem)
 	r.GET("/order_items", orderItemHandler.GetOrderItemsByOrderID)
@@ -114,7 +408,7 @@ func main() {
 	r.PUT("/order_items/:id", orderItemHandler.UpdateOrderItem)
 	r.DELETE("/order_items/:id", orderItemHandler.DeleteOrderItem)
 
-	err := r.Run(":8080")
+	err = r.Run(cfg.Server.Address)
 	if err != nil {
 		log.Fatal("Server run error:", err)
 	}
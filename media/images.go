@@ -0,0 +1,234 @@
+// Package media өнім суреттерін тексереді және олардың кішірейтілген нұсқаларын жасайды.
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"NomadShop/models"
+	"NomadShop/storage"
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("image must be JPEG, PNG or WebP")
+	ErrImageTooLarge    = errors.New("image has too many pixels")
+)
+
+// Size сурет өлшемі: ұзын жағы Max пиксельден аспайды, кішкентай суреттер үлкейтілмейді
+type Size struct {
+	Name string
+	Max  int
+}
+
+var Sizes = []Size{{"thumb", 200}, {"medium", 600}, {"large", 1200}}
+
+// contentTypes http.DetectContentType нәтижесін пішімге сәйкестендіреді
+var contentTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+var extensions = map[string]string{
+	"jpeg": "jpg",
+	"png":  "png",
+	"webp": "webp",
+}
+
+// ContentType пішімнің MIME түрі
+func ContentType(format string) string {
+	for contentType, f := range contentTypes {
+		if f == format {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Extension пішімнің файл кеңейтімі
+func Extension(format string) string {
+	return extensions[format]
+}
+
+// Inspect файлдың шынымен рұқсат етілген сурет екенін тексереді және оның пішімі мен өлшемін
+// қайтарады. Тақырып (Content-Type) емес, файлдың мазмұны тексеріледі. Оқылғаннан кейін r басына қайтарылады.
+func Inspect(r io.ReadSeeker, maxPixels int) (format string, width, height int, err error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", 0, 0, ErrUnsupportedImage
+	}
+	format, ok := contentTypes[http.DetectContentType(head[:n])]
+	if !ok {
+		return "", 0, 0, ErrUnsupportedImage
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", 0, 0, err
+	}
+	config, decoded, err := image.DecodeConfig(r)
+	if err != nil || decoded != format {
+		return "", 0, 0, ErrUnsupportedImage
+	}
+	// Декодтау кезінде жадты толтыратын "сурет бомбаларынан" қорғау
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return "", 0, 0, ErrImageTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", 0, 0, err
+	}
+	return format, config.Width, config.Height, nil
+}
+
+// ProcessPendingImages өңделмеген суреттердің барлық өлшемдерін жасайды. Сурет қысқа
+// транзакцияда алынады да, файлдар транзакциядан тыс өңделеді. Қате болса, сурет кейінірек
+// қайта өңделеді, ал models.ImageMaxAttempts әрекеттен кейін failed күйіне өтеді.
+func ProcessPendingImages(ctx context.Context, db *gorm.DB, store storage.Storage) error {
+	for ctx.Err() == nil {
+		img, err := models.ClaimPendingImage(db)
+		if err != nil || img == nil {
+			return err
+		}
+
+		if err := processImage(ctx, store, img); err != nil {
+			log.Printf("Processing image %d of product %d failed (attempt %d): %v", img.ID, img.ProductID, img.Attempts, err)
+			// Жазылып үлгерген файлдар да сақталады, сонда сурет жойылғанда олар да өшіріледі
+			if img.Attempts >= models.ImageMaxAttempts {
+				img.Status = models.ImageFailed
+			}
+		} else {
+			img.Status = models.ImageReady
+		}
+
+		err = models.FinishImageProcessing(db, img)
+		if errors.Is(err, models.ErrImageNotFound) {
+			// Өңдеу кезінде сурет не өнім жойылды, сондықтан жаңа файлдар ешкімге керек емес
+			DeleteFiles(ctx, store, img)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// DeleteFiles суреттің барлық файлдарын қоймадан өшіреді. Қате тек журналға жазылады:
+// жазба базадан жойылған, ал қалып қойған файл клиентке зиян келтірмейді.
+func DeleteFiles(ctx context.Context, store storage.Storage, img *models.ProductImage) {
+	for _, key := range img.Files {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete image file %s: %v", key, err)
+		}
+	}
+}
+
+func processImage(ctx context.Context, store storage.Storage, img *models.ProductImage) error {
+	original, err := store.Open(ctx, img.Files[0])
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	src, _, err := image.Decode(original)
+	if err != nil {
+		return err
+	}
+
+	// WebP түпнұсқалар үшін кішірейтілген нұсқалар JPEG болады, себебі браузерлердің бәрі WebP көрсетпейді
+	format := "jpeg"
+	if img.Format == "png" {
+		format = "png"
+	}
+
+	for _, size := range Sizes {
+		resized := resize(src, size.Max, format == "jpeg")
+
+		var buf bytes.Buffer
+		if err := encode(&buf, resized, format); err != nil {
+			return err
+		}
+		if err := put(ctx, store, img, size.Name, format, &buf); err != nil {
+			return err
+		}
+
+		buf.Reset()
+		if err := nativewebp.Encode(&buf, resized, nil); err != nil {
+			return err
+		}
+		if err := put(ctx, store, img, size.Name+"_webp", "webp", &buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func put(ctx context.Context, store storage.Storage, img *models.ProductImage, name, format string, r io.Reader) error {
+	key := FileKey(img.StorageKey, sizeFileName(name), format)
+	if err := store.Put(ctx, key, r, ContentType(format)); err != nil {
+		return fmt.Errorf("storing %s: %w", key, err)
+	}
+	if img.URLs == nil {
+		img.URLs = map[string]string{}
+	}
+	img.URLs[name] = store.URL(key)
+	if !slices.Contains(img.Files, key) {
+		img.Files = append(img.Files, key)
+	}
+	return nil
+}
+
+// FileKey сурет файлының қоймадағы кілті, мысалы products/12/3f9a/thumb.webp
+func FileKey(prefix, name, format string) string {
+	return prefix + "/" + name + "." + Extension(format)
+}
+
+// sizeFileName "thumb_webp" атауын файл атауына айналдырады: thumb.webp
+func sizeFileName(name string) string {
+	return strings.TrimSuffix(name, "_webp")
+}
+
+// resize суретті limit шегіне сыйғызады. opaque болса (JPEG үшін), мөлдір аймақтар ақ түске боялады.
+func resize(src image.Image, limit int, opaque bool) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > limit || height > limit {
+		if width >= height {
+			width, height = limit, max(height*limit/width, 1)
+		} else {
+			width, height = max(width*limit/height, 1), limit
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	op := draw.Src
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		op = draw.Over
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, op, nil)
+	return dst
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	if format == "png" {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccessRule маршрутқа қол жеткізу үшін қажет рөлдерді не рұқсатты сипаттайды.
+// Path gin маршрутының үлгісімен сәйкес келуі керек (мысалы, "/products/:id").
+type AccessRule struct {
+	Method     string
+	Path       string
+	Roles      []string
+	Permission string
+}
+
+// Authorize кестеде көрсетілген маршруттар үшін рөлдерді тексереді.
+// Кестеде жоқ маршруттар ашық қалады.
+func Authorize(db *gorm.DB, rules []AccessRule) gin.HandlerFunc {
+	index := make(map[string]AccessRule, len(rules))
+	for _, rule := range rules {
+		index[rule.Method+" "+rule.Path] = rule
+	}
+
+	return func(c *gin.Context) {
+		rule, found := index[c.Request.Method+" "+c.FullPath()]
+		if !found {
+			c.Next()
+			return
+		}
+
+		principal, ok := CurrentPrincipal(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+			return
+		}
+
+		if rule.Permission != "" && !RequirePermission(c, db, rule.Permission) {
+			return
+		}
+
+		if len(rule.Roles) > 0 {
+			allowed, err := principal.HasAnyRole(db, rule.Roles...)
+			if err != nil {
+				log.Printf("Error loading roles for user %d: %v", principal.UserID, err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Error checking permissions"})
+				return
+			}
+			if !allowed {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
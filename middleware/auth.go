@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"NomadShop/auth"
+	"NomadShop/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const principalKey = "principal"
+
+// Principal сұрауды жіберген аутентификацияланған пайдаланушы
+type Principal struct {
+	UserID      uint
+	roles       []string
+	permissions []string
+	loaded      bool
+}
+
+// Authenticate Bearer токенін тексереді. Токен жоқ болса, сұрау анонимді түрде жалғасады.
+func Authenticate(tokens *auth.TokenIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid authorization header"})
+			return
+		}
+
+		userID, err := tokens.ParseAccessToken(strings.TrimSpace(token))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
+			return
+		}
+
+		c.Set(principalKey, &Principal{UserID: userID})
+		c.Next()
+	}
+}
+
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
+
+// Roles пайдаланушы рөлдерін базадан бір рет жүктеп, сұрау ішінде сақтайды
+func (p *Principal) Roles(db *gorm.DB) ([]string, error) {
+	if p.loaded {
+		return p.roles, nil
+	}
+
+	if err := p.load(db); err != nil {
+		return nil, err
+	}
+	return p.roles, nil
+}
+
+func (p *Principal) Permissions(db *gorm.DB) ([]string, error) {
+	if err := p.load(db); err != nil {
+		return nil, err
+	}
+	return p.permissions, nil
+}
+
+func (p *Principal) HasPermission(db *gorm.DB, permission string) (bool, error) {
+	permissions, err := p.Permissions(db)
+	if err != nil {
+		return false, err
+	}
+	for _, have := range permissions {
+		if have == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (p *Principal) load(db *gorm.DB) error {
+	if p.loaded {
+		return nil
+	}
+
+	userRoles, err := models.GetUserRoles(db, p.UserID)
+	if err != nil {
+		return err
+	}
+	permissions, err := models.GetUserPermissions(db, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, userRole := range userRoles {
+		p.roles = append(p.roles, userRole.Role.Name)
+	}
+	p.permissions = permissions
+	p.loaded = true
+	return nil
+}
+
+// RequirePermission ағымдағы пайдаланушыда рұқсат бар-жоғын тексереді.
+// Рұқсат болмаса, жауап жазылып, false қайтарылады.
+func RequirePermission(c *gin.Context, db *gorm.DB, permission string) bool {
+	principal, ok := CurrentPrincipal(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Authentication required"})
+		return false
+	}
+
+	allowed, err := principal.HasPermission(db, permission)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Error checking permissions"})
+		return false
+	}
+	if !allowed {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Forbidden"})
+		return false
+	}
+	return true
+}
+
+func (p *Principal) HasAnyRole(db *gorm.DB, roles ...string) (bool, error) {
+	userRoles, err := p.Roles(db)
+	if err != nil {
+		return false, err
+	}
+	for _, have := range userRoles {
+		for _, want := range roles {
+			if have == want {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORS тек рұқсат етілген шығу көздеріне браузерден сұрау жасауға мүмкіндік береді.
+// Тізімдегі көздерге cookie мен Authorization жіберуге рұқсат етіледі. "*" қалған барлық
+// көздерге рұқсат береді, бірақ credentials-сіз: браузер оларға cookie жібермейді.
+func CORS(origins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		originAllowed := origin != "" && (allowed["*"] || allowed[origin])
+		if originAllowed {
+			if allowed[origin] {
+				c.Header("Access-Control-Allow-Origin", origin)
+				c.Header("Access-Control-Allow-Credentials", "true")
+			} else {
+				c.Header("Access-Control-Allow-Origin", "*")
+			}
+			c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Cart-Token")
+			c.Header("Access-Control-Expose-Headers", "X-Cart-Token")
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Vary", "Origin")
+		}
+
+		// Тек рұқсат етілген көзден келген нақты preflight осында аяқталады,
+		// қалған OPTIONS сұраулары маршрутизаторға жіберіледі.
+		if originAllowed && c.Request.Method == http.MethodOptions &&
+			c.GetHeader("Access-Control-Request-Method") != "" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
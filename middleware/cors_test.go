@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		origins       []string
+		origin        string
+		requestMethod string
+		wantStatus    int
+		wantOrigin    string
+	}{
+		{"allowed preflight", []string{"https://shop.kz"}, "https://shop.kz", "POST", http.StatusNoContent, "https://shop.kz"},
+		{"wildcard preflight", []string{"*"}, "https://other.kz", "GET", http.StatusNoContent, "*"},
+		{"disallowed origin", []string{"https://shop.kz"}, "https://evil.kz", "POST", http.StatusTeapot, ""},
+		{"no origin", []string{"https://shop.kz"}, "", "POST", http.StatusTeapot, ""},
+		{"plain options", []string{"https://shop.kz"}, "https://shop.kz", "", http.StatusTeapot, "https://shop.kz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(CORS(tt.origins))
+			router.OPTIONS("/items", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+
+			req := httptest.NewRequest(http.MethodOptions, "/items", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+CREATE TABLE order_status_histories (
+    id            BIGSERIAL PRIMARY KEY,
+    order_id      BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    from_status   TEXT NOT NULL,
+    to_status     TEXT NOT NULL,
+    changed_by_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    note          TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_order_status_histories_order_id ON order_status_histories (order_id);
+
+-- Бұрынғы "completed" күйі жаңа өмірлік циклде "delivered" деп аталады
+UPDATE orders SET status = 'delivered' WHERE status = 'completed';
@@ -0,0 +1,2 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS chk_products_stock_non_negative;
+DROP TABLE IF EXISTS stock_reservations;
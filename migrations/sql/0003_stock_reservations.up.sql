@@ -0,0 +1,15 @@
+CREATE TABLE stock_reservations (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products (id),
+    quantity   BIGINT NOT NULL CHECK (quantity > 0),
+    status     TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX idx_stock_reservations_order_id ON stock_reservations (order_id);
+CREATE INDEX idx_stock_reservations_held_expires_at ON stock_reservations (expires_at) WHERE status = 'held';
+
+-- Қор ешқашан теріс болмауы керек
+ALTER TABLE products ADD CONSTRAINT chk_products_stock_non_negative CHECK (stock >= 0);
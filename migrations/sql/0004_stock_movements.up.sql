@@ -0,0 +1,17 @@
+CREATE TABLE stock_movements (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    delta      BIGINT NOT NULL,
+    reason     TEXT NOT NULL CHECK (reason IN ('sale', 'return', 'adjustment', 'import')),
+    order_id   BIGINT REFERENCES orders (id) ON DELETE SET NULL,
+    user_id    BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    note       TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_stock_movements_product_id ON stock_movements (product_id);
+
+-- Ledger ағымдағы қормен сәйкес болуы үшін бастапқы қалдықты жазу
+INSERT INTO stock_movements (product_id, delta, reason, note)
+SELECT id, stock, 'import', 'opening balance'
+FROM products
+WHERE stock <> 0;
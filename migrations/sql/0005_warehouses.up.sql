@@ -0,0 +1,38 @@
+CREATE TABLE warehouses (
+    id         BIGSERIAL PRIMARY KEY,
+    name       TEXT NOT NULL UNIQUE,
+    city       TEXT NOT NULL DEFAULT '',
+    priority   INTEGER NOT NULL DEFAULT 100,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE warehouse_stocks (
+    id           BIGSERIAL PRIMARY KEY,
+    warehouse_id BIGINT NOT NULL REFERENCES warehouses (id),
+    product_id   BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity     BIGINT NOT NULL CHECK (quantity >= 0)
+);
+CREATE UNIQUE INDEX idx_warehouse_stocks_warehouse_product ON warehouse_stocks (warehouse_id, product_id);
+CREATE INDEX idx_warehouse_stocks_product_id ON warehouse_stocks (product_id);
+
+-- Бұрынғы бір Stock бағаны әдепкі қоймаға көшіріледі
+INSERT INTO warehouses (name, city, priority, created_at, updated_at)
+VALUES ('Default', '', 0, now(), now());
+
+INSERT INTO warehouse_stocks (warehouse_id, product_id, quantity)
+SELECT w.id, p.id, p.stock
+FROM products p
+CROSS JOIN (SELECT id FROM warehouses WHERE name = 'Default') w;
+
+ALTER TABLE stock_movements ADD COLUMN warehouse_id BIGINT REFERENCES warehouses (id);
+UPDATE stock_movements SET warehouse_id = (SELECT id FROM warehouses WHERE name = 'Default');
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE stock_reservations ADD COLUMN warehouse_id BIGINT REFERENCES warehouses (id);
+UPDATE stock_reservations SET warehouse_id = (SELECT id FROM warehouses WHERE name = 'Default');
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+
+-- Бұрынғы тапсырыстарда қойма белгісіз, сондықтан баған бос болуы мүмкін
+ALTER TABLE order_items ADD COLUMN warehouse_id BIGINT REFERENCES warehouses (id);
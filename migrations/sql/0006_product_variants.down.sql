@@ -0,0 +1,18 @@
+ALTER TABLE favorite_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS variant_id;
+
+-- Бір өнімнің бірнеше нұсқасы бір қоймада болуы мүмкін, сондықтан қалдықтар қосылады
+UPDATE warehouse_stocks ws SET quantity = t.quantity
+FROM (SELECT warehouse_id, product_id, SUM(quantity) AS quantity
+      FROM warehouse_stocks GROUP BY warehouse_id, product_id) t
+WHERE ws.warehouse_id = t.warehouse_id AND ws.product_id = t.product_id;
+DELETE FROM warehouse_stocks a USING warehouse_stocks b
+WHERE a.warehouse_id = b.warehouse_id AND a.product_id = b.product_id AND a.id > b.id;
+DROP INDEX IF EXISTS idx_warehouse_stocks_warehouse_variant;
+ALTER TABLE warehouse_stocks DROP COLUMN IF EXISTS variant_id;
+CREATE UNIQUE INDEX idx_warehouse_stocks_warehouse_product ON warehouse_stocks (warehouse_id, product_id);
+
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,52 @@
+CREATE TABLE product_variants (
+    id         BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    sku        TEXT NOT NULL UNIQUE,
+    color      TEXT NOT NULL DEFAULT '',
+    size       TEXT NOT NULL DEFAULT '',
+    price      BIGINT CHECK (price >= 0),
+    image      TEXT NOT NULL DEFAULT '',
+    stock      BIGINT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE INDEX idx_product_variants_product_id ON product_variants (product_id);
+CREATE UNIQUE INDEX idx_product_variants_options ON product_variants (product_id, color, size);
+CREATE UNIQUE INDEX idx_product_variants_default ON product_variants (product_id) WHERE is_default;
+
+-- Әр бұрынғы өнім өз түсі, өлшемі және қоры бар бір әдепкі нұсқаға айналады
+INSERT INTO product_variants (product_id, sku, color, size, image, stock, is_default, created_at, updated_at)
+SELECT id, 'P' || id, color, size, image, stock, TRUE, now(), now()
+FROM products;
+
+ALTER TABLE warehouse_stocks ADD COLUMN variant_id BIGINT REFERENCES product_variants (id) ON DELETE CASCADE;
+UPDATE warehouse_stocks ws SET variant_id = v.id
+FROM product_variants v WHERE v.product_id = ws.product_id AND v.is_default;
+ALTER TABLE warehouse_stocks ALTER COLUMN variant_id SET NOT NULL;
+DROP INDEX idx_warehouse_stocks_warehouse_product;
+CREATE UNIQUE INDEX idx_warehouse_stocks_warehouse_variant ON warehouse_stocks (warehouse_id, variant_id);
+
+ALTER TABLE stock_movements ADD COLUMN variant_id BIGINT REFERENCES product_variants (id) ON DELETE CASCADE;
+UPDATE stock_movements m SET variant_id = v.id
+FROM product_variants v WHERE v.product_id = m.product_id AND v.is_default;
+ALTER TABLE stock_movements ALTER COLUMN variant_id SET NOT NULL;
+CREATE INDEX idx_stock_movements_variant_id ON stock_movements (variant_id);
+
+ALTER TABLE stock_reservations ADD COLUMN variant_id BIGINT REFERENCES product_variants (id);
+UPDATE stock_reservations r SET variant_id = v.id
+FROM product_variants v WHERE v.product_id = r.product_id AND v.is_default;
+ALTER TABLE stock_reservations ALTER COLUMN variant_id SET NOT NULL;
+
+ALTER TABLE cart_items ADD COLUMN variant_id BIGINT REFERENCES product_variants (id) ON DELETE CASCADE;
+UPDATE cart_items c SET variant_id = v.id
+FROM product_variants v WHERE v.product_id = c.product_id AND v.is_default;
+ALTER TABLE cart_items ALTER COLUMN variant_id SET NOT NULL;
+
+ALTER TABLE order_items ADD COLUMN variant_id BIGINT REFERENCES product_variants (id);
+UPDATE order_items o SET variant_id = v.id
+FROM product_variants v WHERE v.product_id = o.product_id AND v.is_default;
+ALTER TABLE order_items ALTER COLUMN variant_id SET NOT NULL;
+
+-- Таңдаулыларда нұсқа міндетті емес: бос болса, өнім тұтастай таңдалған
+ALTER TABLE favorite_items ADD COLUMN variant_id BIGINT REFERENCES product_variants (id) ON DELETE CASCADE;
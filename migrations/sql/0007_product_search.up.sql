@@ -0,0 +1,17 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- PostgreSQL-де қазақ тілінің стеммері жоқ, сондықтан kazakh конфигурациясы әзірге simple-дің
+-- көшірмесі. Кейін оған hunspell сөздігін қосуға болады (ALTER TEXT SEARCH CONFIGURATION).
+CREATE TEXT SEARCH CONFIGURATION kazakh (COPY = pg_catalog.simple);
+
+ALTER TABLE products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', name), 'A') ||
+    setweight(to_tsvector('english', name), 'A') ||
+    setweight(to_tsvector('kazakh', name), 'A') ||
+    setweight(to_tsvector('russian', description), 'B') ||
+    setweight(to_tsvector('english', description), 'B') ||
+    setweight(to_tsvector('kazakh', description), 'B')
+) STORED;
+
+CREATE INDEX idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
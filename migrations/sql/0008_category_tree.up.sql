@@ -0,0 +1,20 @@
+ALTER TABLE categories ADD COLUMN parent_id BIGINT REFERENCES categories (id);
+ALTER TABLE categories ADD COLUMN slug TEXT;
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+
+-- Бар санаттардың slug-ы атаудан жасалады (Slugify-дағыдай транслитерация)
+UPDATE categories SET slug = trim(BOTH '-' FROM regexp_replace(
+    translate(
+        replace(replace(replace(replace(replace(replace(replace(lower(name),
+            'щ', 'sch'), 'ж', 'zh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'ю', 'yu'), 'я', 'ya'),
+        'аәбвгғдеёзиійкқлмнңоөпрстуұүфхһыэъь',
+        'aabvggdeeziiikqlmnnooprstuuufhhye'),
+    '[^a-z0-9]+', '-', 'g'));
+UPDATE categories SET slug = 'category' WHERE slug = '';
+
+-- Қайталанған slug-тарға id қосылады, бірінші санат өзгеріссіз қалады
+UPDATE categories c SET slug = c.slug || '-' || c.id
+WHERE EXISTS (SELECT 1 FROM categories o WHERE o.slug = c.slug AND o.id < c.id);
+
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX idx_categories_slug ON categories (slug);
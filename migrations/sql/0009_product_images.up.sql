@@ -0,0 +1,20 @@
+CREATE TABLE product_images (
+    id          BIGSERIAL PRIMARY KEY,
+    product_id  BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL,
+    format      TEXT NOT NULL,
+    width       BIGINT NOT NULL,
+    height      BIGINT NOT NULL,
+    alt_text    TEXT NOT NULL DEFAULT '',
+    position    BIGINT NOT NULL,
+    is_primary  BOOLEAN NOT NULL DEFAULT FALSE,
+    status      TEXT NOT NULL,
+    urls        JSONB NOT NULL DEFAULT '{}',
+    files       JSONB NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_product_images_product_id ON product_images (product_id, position);
+CREATE UNIQUE INDEX idx_product_images_primary ON product_images (product_id) WHERE is_primary;
+-- Фондық өңдеу тек pending суреттерді іздейді
+CREATE INDEX idx_product_images_pending ON product_images (id) WHERE status = 'pending';
@@ -0,0 +1,22 @@
+-- Валюта бағандары жойылады: кері миграция тек KZT бағалары үшін дұрыс.
+-- Тауар бағалары бүтін теңгеге дейін дөңгелектенеді.
+ALTER TABLE order_items ADD COLUMN price DECIMAL;
+UPDATE order_items SET price = price_amount / 100.0;
+ALTER TABLE order_items ALTER COLUMN price SET NOT NULL;
+ALTER TABLE order_items DROP COLUMN price_amount;
+ALTER TABLE order_items DROP COLUMN price_currency;
+
+ALTER TABLE orders ADD COLUMN total DECIMAL;
+UPDATE orders SET total = total_amount / 100.0;
+ALTER TABLE orders ALTER COLUMN total SET NOT NULL;
+ALTER TABLE orders DROP COLUMN total_amount;
+ALTER TABLE orders DROP COLUMN total_currency;
+
+ALTER TABLE product_variants DROP CONSTRAINT IF EXISTS product_variants_price_currency_check;
+ALTER TABLE product_variants DROP COLUMN price_currency;
+UPDATE product_variants SET price_amount = round(price_amount / 100.0) WHERE price_amount IS NOT NULL;
+ALTER TABLE product_variants RENAME COLUMN price_amount TO price;
+
+ALTER TABLE products DROP COLUMN price_currency;
+UPDATE products SET price_amount = round(price_amount / 100.0);
+ALTER TABLE products RENAME COLUMN price_amount TO price;
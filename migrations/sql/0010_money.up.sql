@@ -0,0 +1,37 @@
+-- Бағалар ең кіші бірлікпен (тиын) бүтін сан ретінде сақталады, валютасы жанында тұрады.
+-- Бұрынғы бағалар бүтін теңгемен жазылған, сондықтан 100-ге көбейтіледі.
+
+-- DECIMAL бағандарда тиыннан кіші бөлшек болса, оны үнсіз дөңгелектемей, миграцияны тоқтатамыз
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM orders WHERE total * 100 <> trunc(total * 100))
+        OR EXISTS (SELECT 1 FROM order_items WHERE price * 100 <> trunc(price * 100)) THEN
+        RAISE EXCEPTION 'orders.total or order_items.price has fractions below 0.01, fix them before migrating';
+    END IF;
+END $$;
+
+ALTER TABLE products RENAME COLUMN price TO price_amount;
+UPDATE products SET price_amount = price_amount * 100;
+ALTER TABLE products ADD COLUMN price_currency CHAR(3) NOT NULL DEFAULT 'KZT';
+ALTER TABLE products ALTER COLUMN price_currency DROP DEFAULT;
+
+ALTER TABLE product_variants RENAME COLUMN price TO price_amount;
+UPDATE product_variants SET price_amount = price_amount * 100 WHERE price_amount IS NOT NULL;
+ALTER TABLE product_variants ADD COLUMN price_currency CHAR(3);
+UPDATE product_variants SET price_currency = 'KZT' WHERE price_amount IS NOT NULL;
+ALTER TABLE product_variants ADD CONSTRAINT product_variants_price_currency_check
+    CHECK ((price_amount IS NULL) = (price_currency IS NULL));
+
+ALTER TABLE orders ADD COLUMN total_amount BIGINT;
+ALTER TABLE orders ADD COLUMN total_currency CHAR(3);
+UPDATE orders SET total_amount = (total * 100)::BIGINT, total_currency = 'KZT';
+ALTER TABLE orders ALTER COLUMN total_amount SET NOT NULL;
+ALTER TABLE orders ALTER COLUMN total_currency SET NOT NULL;
+ALTER TABLE orders DROP COLUMN total;
+
+ALTER TABLE order_items ADD COLUMN price_amount BIGINT;
+ALTER TABLE order_items ADD COLUMN price_currency CHAR(3);
+UPDATE order_items SET price_amount = (price * 100)::BIGINT, price_currency = 'KZT';
+ALTER TABLE order_items ALTER COLUMN price_amount SET NOT NULL;
+ALTER TABLE order_items ALTER COLUMN price_currency SET NOT NULL;
+ALTER TABLE order_items DROP COLUMN price;
@@ -0,0 +1,11 @@
+-- Бағам: валютаның бір бірлігі негізгі валютаның қанша бірлігіне тең
+CREATE TABLE exchange_rates (
+    id         BIGSERIAL PRIMARY KEY,
+    currency   CHAR(3) NOT NULL,
+    rate       NUMERIC(20, 8) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX idx_exchange_rates_currency ON exchange_rates (currency);
+
+-- Бұрынғы тапсырыстардың бәрі негізгі валютада рәсімделген
+ALTER TABLE orders ADD COLUMN exchange_rate NUMERIC(20, 8) NOT NULL DEFAULT 1 CHECK (exchange_rate > 0);
@@ -0,0 +1,9 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS subtotal_amount,
+    DROP COLUMN IF EXISTS subtotal_currency,
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS discount_currency,
+    DROP COLUMN IF EXISTS shipping_amount,
+    DROP COLUMN IF EXISTS shipping_currency;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,55 @@
+CREATE TABLE coupons (
+    id                 BIGSERIAL PRIMARY KEY,
+    code               TEXT NOT NULL,
+    type               TEXT NOT NULL CHECK (type IN ('percentage', 'fixed', 'free_shipping')),
+    percent            BIGINT NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount_amount      BIGINT,
+    amount_currency    CHAR(3),
+    min_order_amount   BIGINT,
+    min_order_currency CHAR(3),
+    product_ids        JSONB NOT NULL DEFAULT '[]',
+    category_ids       JSONB NOT NULL DEFAULT '[]',
+    usage_limit        BIGINT CHECK (usage_limit > 0),
+    per_user_limit     BIGINT CHECK (per_user_limit > 0),
+    starts_at          TIMESTAMPTZ,
+    ends_at            TIMESTAMPTZ,
+    active             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((amount_amount IS NULL) = (amount_currency IS NULL)),
+    CHECK ((min_order_amount IS NULL) = (min_order_currency IS NULL)),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+CREATE UNIQUE INDEX idx_coupons_code ON coupons (code);
+
+-- Қолданылған купон есептер үшін сақталады, сондықтан оны жою мүмкін емес
+CREATE TABLE coupon_redemptions (
+    id                BIGSERIAL PRIMARY KEY,
+    coupon_id         BIGINT NOT NULL REFERENCES coupons (id) ON DELETE RESTRICT,
+    order_id          BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    user_id           BIGINT NOT NULL,
+    code              TEXT NOT NULL,
+    discount_amount   BIGINT NOT NULL,
+    discount_currency CHAR(3) NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_coupon_redemptions_coupon_id ON coupon_redemptions (coupon_id, user_id);
+CREATE INDEX idx_coupon_redemptions_order_id ON coupon_redemptions (order_id);
+
+-- Бұрынғы тапсырыстарда жеңілдік пен жеткізу болмаған: subtotal = total
+ALTER TABLE orders
+    ADD COLUMN subtotal_amount   BIGINT,
+    ADD COLUMN subtotal_currency CHAR(3),
+    ADD COLUMN discount_amount   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN discount_currency CHAR(3),
+    ADD COLUMN shipping_amount   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN shipping_currency CHAR(3);
+UPDATE orders SET subtotal_amount = total_amount,
+                  subtotal_currency = total_currency,
+                  discount_currency = total_currency,
+                  shipping_currency = total_currency;
+ALTER TABLE orders
+    ALTER COLUMN subtotal_amount SET NOT NULL,
+    ALTER COLUMN subtotal_currency SET NOT NULL,
+    ALTER COLUMN discount_currency SET NOT NULL,
+    ALTER COLUMN shipping_currency SET NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS discount_amount,
+    DROP COLUMN IF EXISTS discount_currency,
+    DROP COLUMN IF EXISTS discounts;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,32 @@
+CREATE TABLE promotions (
+    id                    BIGSERIAL PRIMARY KEY,
+    name                  TEXT NOT NULL,
+    type                  TEXT NOT NULL CHECK (type IN ('buy_x_get_y', 'tiered', 'bundle')),
+    priority              BIGINT NOT NULL DEFAULT 0,
+    stackable             BOOLEAN NOT NULL DEFAULT FALSE,
+    product_ids           JSONB NOT NULL DEFAULT '[]',
+    category_ids          JSONB NOT NULL DEFAULT '[]',
+    buy_quantity          BIGINT NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity          BIGINT NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+    get_percent           BIGINT NOT NULL DEFAULT 0 CHECK (get_percent BETWEEN 0 AND 100),
+    tiers                 JSONB NOT NULL DEFAULT '[]',
+    bundle_quantity       BIGINT NOT NULL DEFAULT 0 CHECK (bundle_quantity >= 0),
+    bundle_price_amount   BIGINT,
+    bundle_price_currency CHAR(3),
+    starts_at             TIMESTAMPTZ,
+    ends_at               TIMESTAMPTZ,
+    active                BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((bundle_price_amount IS NULL) = (bundle_price_currency IS NULL)),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at)
+);
+CREATE INDEX idx_promotions_active ON promotions (priority DESC, id) WHERE active;
+
+-- Бұрынғы тапсырыс жолдарында жеңілдік болмаған
+ALTER TABLE order_items
+    ADD COLUMN discount_amount   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN discount_currency CHAR(3),
+    ADD COLUMN discounts         JSONB NOT NULL DEFAULT '[]';
+UPDATE order_items SET discount_currency = price_currency;
+ALTER TABLE order_items ALTER COLUMN discount_currency SET NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS tax_currency,
+    DROP COLUMN IF EXISTS tax_included;
+ALTER TABLE cart_items
+    DROP COLUMN IF EXISTS added_price_amount,
+    DROP COLUMN IF EXISTS added_price_currency;
@@ -0,0 +1,13 @@
+-- Себетке қосылған кездегі баға; бұрынғы жолдарда белгісіз
+ALTER TABLE cart_items
+    ADD COLUMN added_price_amount   BIGINT,
+    ADD COLUMN added_price_currency CHAR(3),
+    ADD CHECK ((added_price_amount IS NULL) = (added_price_currency IS NULL));
+
+-- Бұрынғы тапсырыстарда салық болмаған
+ALTER TABLE orders
+    ADD COLUMN tax_amount   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN tax_currency CHAR(3),
+    ADD COLUMN tax_included BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE orders SET tax_currency = total_currency;
+ALTER TABLE orders ALTER COLUMN tax_currency SET NOT NULL;
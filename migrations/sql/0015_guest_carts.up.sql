@@ -0,0 +1,21 @@
+CREATE TABLE guest_carts (
+    id         BIGSERIAL PRIMARY KEY,
+    token_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX idx_guest_carts_token_hash ON guest_carts (token_hash);
+CREATE INDEX idx_guest_carts_updated_at ON guest_carts (updated_at);
+
+CREATE TABLE guest_cart_items (
+    id                   BIGSERIAL PRIMARY KEY,
+    guest_cart_id        BIGINT NOT NULL REFERENCES guest_carts (id) ON DELETE CASCADE,
+    product_id           BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    variant_id           BIGINT NOT NULL REFERENCES product_variants (id) ON DELETE CASCADE,
+    quantity             BIGINT NOT NULL CHECK (quantity > 0),
+    added_price_amount   BIGINT,
+    added_price_currency CHAR(3),
+    CHECK ((added_price_amount IS NULL) = (added_price_currency IS NULL))
+);
+-- Бір нұсқа себетте екі рет болмайды
+CREATE UNIQUE INDEX idx_guest_cart_items_variant ON guest_cart_items (guest_cart_id, variant_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS abandoned_carts;
+ALTER TABLE users DROP COLUMN IF EXISTS cart_reminders_opt_out;
+DROP INDEX IF EXISTS idx_cart_items_user_id;
+ALTER TABLE cart_items
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,24 @@
+-- Бұрынғы жолдардың қосылған уақыты белгісіз, миграция уақыты алынады
+ALTER TABLE cart_items
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX idx_cart_items_user_id ON cart_items (user_id, updated_at);
+
+ALTER TABLE users ADD COLUMN cart_reminders_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE abandoned_carts (
+    id                BIGSERIAL PRIMARY KEY,
+    user_id           BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    last_activity_at  TIMESTAMPTZ NOT NULL,
+    item_count        BIGINT NOT NULL,
+    subtotal_amount   BIGINT NOT NULL,
+    subtotal_currency CHAR(3) NOT NULL,
+    status            TEXT NOT NULL CHECK (status IN ('pending', 'sent', 'suppressed')),
+    reason            TEXT NOT NULL DEFAULT '',
+    notified_at       TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- Бір себеттің бір тыныштық кезеңі үшін бір оқиға
+CREATE UNIQUE INDEX idx_abandoned_carts_activity ON abandoned_carts (user_id, last_activity_at);
+CREATE INDEX idx_abandoned_carts_pending ON abandoned_carts (id) WHERE status = 'pending';
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+UPDATE orders o SET status = h.from_status
+FROM order_status_histories h
+WHERE h.order_id = o.id AND h.note = 'legacy status backfill' AND o.status = h.to_status;
+DELETE FROM order_status_histories WHERE note = 'legacy status backfill';
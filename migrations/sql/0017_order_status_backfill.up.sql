@@ -0,0 +1,42 @@
+-- 0002 тек "completed" күйін ауыстырды. Қалған бұрынғы күйлер жаңа өмірлік циклге
+-- сәйкестендіріледі, танылмағандары pending болады (әкімші оларды әрі қарай ауыстыра алады).
+-- Бұрынғы мән күй тарихында сақталады.
+WITH legacy AS (
+    SELECT id,
+           status AS old_status,
+           CASE lower(btrim(status))
+               WHEN 'pending' THEN 'pending'
+               WHEN 'new' THEN 'pending'
+               WHEN 'created' THEN 'pending'
+               WHEN 'open' THEN 'pending'
+               WHEN 'awaiting_payment' THEN 'pending'
+               WHEN 'unpaid' THEN 'pending'
+               WHEN 'paid' THEN 'paid'
+               WHEN 'confirmed' THEN 'paid'
+               WHEN 'processing' THEN 'processing'
+               WHEN 'in_progress' THEN 'processing'
+               WHEN 'shipped' THEN 'shipped'
+               WHEN 'sent' THEN 'shipped'
+               WHEN 'in_transit' THEN 'shipped'
+               WHEN 'delivered' THEN 'delivered'
+               WHEN 'completed' THEN 'delivered'
+               WHEN 'complete' THEN 'delivered'
+               WHEN 'done' THEN 'delivered'
+               WHEN 'finished' THEN 'delivered'
+               WHEN 'cancelled' THEN 'cancelled'
+               WHEN 'canceled' THEN 'cancelled'
+               WHEN 'rejected' THEN 'cancelled'
+               WHEN 'refunded' THEN 'refunded'
+               WHEN 'returned' THEN 'refunded'
+               ELSE 'pending'
+           END AS new_status
+    FROM orders
+    WHERE status NOT IN ('pending', 'paid', 'processing', 'shipped', 'delivered', 'cancelled', 'refunded')
+), history AS (
+    INSERT INTO order_status_histories (order_id, from_status, to_status, note)
+    SELECT id, old_status, new_status, 'legacy status backfill' FROM legacy
+)
+UPDATE orders o SET status = l.new_status FROM legacy l WHERE o.id = l.id;
+
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('pending', 'paid', 'processing', 'shipped', 'delivered', 'cancelled', 'refunded'));
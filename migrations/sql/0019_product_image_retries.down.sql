@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_product_images_pending;
+CREATE INDEX idx_product_images_pending ON product_images (id) WHERE status = 'pending';
+ALTER TABLE product_images
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS next_attempt_at;
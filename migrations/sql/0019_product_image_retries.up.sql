@@ -0,0 +1,6 @@
+-- Сурет өңдеуге алынғанда attempts артады және next_attempt_at кейінге жылжиды
+ALTER TABLE product_images
+    ADD COLUMN attempts        BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+DROP INDEX IF EXISTS idx_product_images_pending;
+CREATE INDEX idx_product_images_pending ON product_images (next_attempt_at, id) WHERE status = 'pending';
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"NomadShop/money"
+	"NomadShop/notify"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	AbandonedCartPending    = "pending"    // еске салу жіберілуін күтуде
+	AbandonedCartSent       = "sent"       // еске салу жіберілді
+	AbandonedCartSuppressed = "suppressed" // еске салу жіберілмейді, себебі Reason-да
+)
+
+const (
+	SuppressedCheckedOut  = "checked_out"
+	SuppressedOptedOut    = "opted_out"
+	SuppressedCartChanged = "cart_changed"
+)
+
+// AbandonedCart себеттің бос жатып қалғанын тіркейді: бір оқиға себеттегі соңғы өзгеріс
+// (LastActivityAt) үшін бір рет жазылады. Pending оқиғалар еске салу кезегі болып табылады.
+type AbandonedCart struct {
+	ID             uint        `gorm:"primaryKey"`
+	UserID         uint        `gorm:"not null;index"`
+	LastActivityAt time.Time   `gorm:"not null"`
+	ItemCount      int         `gorm:"not null"`
+	Subtotal       money.Money `gorm:"embedded;embeddedPrefix:subtotal_"` // тіркелген кездегі, негізгі валютада
+	Status         string      `gorm:"not null"`                          // AbandonedCart* тұрақтыларының бірі
+	Reason         string      `gorm:"not null"`                          // Suppressed* тұрақтыларының бірі
+	NotifiedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// SetCartReminders пайдаланушының себет туралы еске салуларды алу-алмауын сақтайды
+func SetCartReminders(db *gorm.DB, userID uint, enabled bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&User{}).Where("id = ?", userID).Update("cart_reminders_opt_out", !enabled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if enabled {
+			return nil
+		}
+		return suppressAbandonedCarts(tx, userID, SuppressedOptedOut)
+	})
+}
+
+// DetectAbandonedCarts соңғы өзгерісі idleAfter-дан бұрын болған себеттер үшін оқиға жазады.
+// Осы себет үшін оқиға бұрын жазылған болса (немесе жолдар тек жойылған болса), жаңасы жазылмайды.
+// Еске салудан бас тартқан пайдаланушылардың оқиғалары бірден suppressed болады.
+// Бір себеттің қатесі қалғандарын тоқтатпайды; жазылған оқиғалар саны қайтарылады.
+func DetectAbandonedCarts(db *gorm.DB, settings Settings, idleAfter time.Duration) (int, error) {
+	var idle []struct {
+		UserID         uint
+		LastActivityAt time.Time
+		ItemCount      int
+	}
+	err := db.Model(&CartItem{}).
+		Select("user_id, MAX(updated_at) AS last_activity_at, COUNT(*) AS item_count").
+		Group("user_id").
+		Having("MAX(updated_at) <= ?", time.Now().Add(-idleAfter)).
+		Having("NOT EXISTS (SELECT 1 FROM abandoned_carts a WHERE a.user_id = cart_items.user_id AND a.last_activity_at >= MAX(cart_items.updated_at))").
+		Order("user_id").
+		Scan(&idle).Error
+	if err != nil {
+		return 0, err
+	}
+
+	detected := 0
+	var errs []error
+	for _, cart := range idle {
+		summary, err := SummarizeCart(db, settings, cart.UserID, "", "")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("abandoned cart of user %d: %w", cart.UserID, err))
+			continue
+		}
+		var user User
+		if err := db.Select("id", "cart_reminders_opt_out").First(&user, cart.UserID).Error; err != nil {
+			errs = append(errs, fmt.Errorf("abandoned cart of user %d: %w", cart.UserID, err))
+			continue
+		}
+
+		event := AbandonedCart{
+			UserID:         cart.UserID,
+			LastActivityAt: cart.LastActivityAt,
+			ItemCount:      cart.ItemCount,
+			Subtotal:       summary.Subtotal,
+			Status:         AbandonedCartPending,
+		}
+		if user.CartRemindersOptOut {
+			event.Status = AbandonedCartSuppressed
+			event.Reason = SuppressedOptedOut
+		}
+		// Бірнеше реплика қатар іздесе, оқиға бір рет жазылады
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+		if result.Error != nil {
+			errs = append(errs, fmt.Errorf("abandoned cart of user %d: %w", cart.UserID, result.Error))
+			continue
+		}
+		detected += int(result.RowsAffected)
+	}
+	return detected, errors.Join(errs...)
+}
+
+// SendCartReminders pending оқиғалар бойынша еске салуларды notifier арқылы жібереді.
+// Жіберер алдында пайдаланушы тапсырыс берген, бас тартқан немесе себетін өзгерткен болса,
+// оқиға suppressed болады. Оқиға алдымен sent деп белгіленіп, транзакция аяқталады да, хабарлама
+// транзакциядан тыс жіберіледі; жіберу сәтсіз болса, оқиға pending күйіне қайтарылады.
+func SendCartReminders(ctx context.Context, db *gorm.DB, notifier notify.Notifier) (int, error) {
+	var ids []uint
+	if err := db.Model(&AbandonedCart{}).Where("status = ?", AbandonedCartPending).Order("id").Limit(100).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, id := range ids {
+		event, user, err := claimCartReminder(db, id)
+		if err == nil && event != nil {
+			if err = notifier.Send(ctx, cartReminder(event, user)); err != nil {
+				// Келесі жолы қайта жіберу үшін
+				revert := db.Model(&AbandonedCart{}).Where("id = ? AND status = ?", event.ID, AbandonedCartSent).
+					Updates(map[string]interface{}{"status": AbandonedCartPending, "notified_at": nil})
+				err = errors.Join(err, revert.Error)
+			} else {
+				sent++
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("abandoned cart %d: %w", id, err))
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// claimCartReminder pending оқиғаны құлыптап, sent деп белгілейді. Оқиға басқа репликада
+// өңделіп жатса, бұрын өңделген болса немесе suppressed болса, nil қайтарылады.
+func claimCartReminder(db *gorm.DB, id uint) (*AbandonedCart, *User, error) {
+	var event AbandonedCart
+	var user User
+	claimed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Басқа реплика өңдеп жатқан оқиғалар өткізіп жіберіледі
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", AbandonedCartPending).First(&event, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, event.UserID).Error; err != nil {
+			return err
+		}
+		reason, err := reminderSuppression(tx, &event, &user)
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			return tx.Model(&event).Updates(map[string]interface{}{"status": AbandonedCartSuppressed, "reason": reason}).Error
+		}
+
+		if err := tx.Model(&event).Updates(map[string]interface{}{"status": AbandonedCartSent, "notified_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	if err != nil || !claimed {
+		return nil, nil, err
+	}
+	return &event, &user, nil
+}
+
+// reminderSuppression еске салуды неге жібермеу керектігін қайтарады; жіберу керек болса, бос жол
+func reminderSuppression(db *gorm.DB, event *AbandonedCart, user *User) (string, error) {
+	if user.CartRemindersOptOut {
+		return SuppressedOptedOut, nil
+	}
+
+	var orders int64
+	if err := db.Model(&Order{}).Where("user_id = ? AND order_date > ?", user.ID, event.LastActivityAt).Count(&orders).Error; err != nil {
+		return "", err
+	}
+	if orders > 0 {
+		return SuppressedCheckedOut, nil
+	}
+
+	var lastActivity struct {
+		ItemCount      int
+		LastActivityAt *time.Time
+	}
+	err := db.Model(&CartItem{}).Select("COUNT(*) AS item_count, MAX(updated_at) AS last_activity_at").
+		Where("user_id = ?", user.ID).Scan(&lastActivity).Error
+	if err != nil {
+		return "", err
+	}
+	if lastActivity.ItemCount == 0 || lastActivity.ItemCount != event.ItemCount || lastActivity.LastActivityAt.After(event.LastActivityAt) {
+		return SuppressedCartChanged, nil
+	}
+	return "", nil
+}
+
+func cartReminder(event *AbandonedCart, user *User) notify.Message {
+	return notify.Message{
+		UserID:  user.ID,
+		To:      user.Email,
+		Kind:    "cart_reminder",
+		Key:     fmt.Sprintf("cart_reminder:%d", event.ID),
+		Subject: "You left items in your cart",
+		Body: fmt.Sprintf("Hi %s, you still have %d item(s) worth %s in your cart. Complete your order before they sell out.",
+			user.Username, event.ItemCount, event.Subtotal),
+	}
+}
+
+// suppressAbandonedCarts пайдаланушының жіберілмеген еске салуларын тоқтатады
+func suppressAbandonedCarts(db *gorm.DB, userID uint, reason string) error {
+	return db.Model(&AbandonedCart{}).Where("user_id = ? AND status = ?", userID, AbandonedCartPending).
+		Updates(map[string]interface{}{"status": AbandonedCartSuppressed, "reason": reason}).Error
+}
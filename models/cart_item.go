@@ -1,24 +1,35 @@
 package models
 
 import (
+	"time"
+
+	"NomadShop/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// CartItem себеттегі жол. VariantID берілмесе, өнімнің әдепкі нұсқасы қолданылады.
 type CartItem struct {
-	ID        uint    `gorm:"primaryKey"`
-	UserID    uint    `gorm:"not null"`
-	ProductID uint    `gorm:"not null"`
-	Quantity  uint    `gorm:"not null"`
-	Product   Product `gorm:"foreignKey:ProductID;references:ID"`
+	ID         uint           `gorm:"primaryKey"`
+	UserID     uint           `gorm:"not null"`
+	ProductID  uint           `gorm:"not null"`
+	VariantID  uint           `gorm:"not null"`
+	Quantity   uint           `gorm:"not null"`
+	AddedPrice *money.Money   `gorm:"embedded;embeddedPrefix:added_price_"` // қосылған кездегі бірлік бағасы; бұрынғы жазбаларда жоқ
+	Product    Product        `gorm:"foreignKey:ProductID;references:ID"`
+	Variant    ProductVariant `gorm:"foreignKey:VariantID;references:ID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time // соңғы өзгеріс; ұзақ өзгермесе, себет тасталған деп саналады
 }
 
 func AddToCart(db *gorm.DB, cartItem *CartItem) (*CartItem, error) {
-	err := db.Create(&cartItem).Error
+	// Клиент жіберген Product/Variant объектілері базаға жазылмауы керек
+	err := db.Omit(clause.Associations).Create(&cartItem).Error
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Preload("Product").Preload("Product.Category").First(&cartItem, cartItem.ID).Error
+	err = db.Preload("Product").Preload("Product.Category").Preload("Variant").First(&cartItem, cartItem.ID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -28,13 +39,13 @@ func AddToCart(db *gorm.DB, cartItem *CartItem) (*CartItem, error) {
 
 func GetCartItems(db *gorm.DB, userID uint) ([]CartItem, error) {
 	var cartItems []CartItem
-	err := db.Preload("Product").Where("user_id = ?", userID).Find(&cartItems).Error
+	err := db.Preload("Product").Preload("Variant").Where("user_id = ?", userID).Find(&cartItems).Error
 	return cartItems, err
 }
 
 func GetCartItemByID(db *gorm.DB, id uint) (*CartItem, error) {
 	var cartItem CartItem
-	err := db.Preload("Product").First(&cartItem, id).Error
+	err := db.Preload("Product").Preload("Variant").First(&cartItem, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+)
+
+const (
+	CartWarningPriceChanged = "price_changed"
+	CartWarningLowStock     = "insufficient_stock"
+	CartWarningUnavailable  = "product_unavailable"
+)
+
+// CartWarning себет жолын рәсімдеу алдында клиентке көрсету керек өзгеріс
+type CartWarning struct {
+	CartItemID    uint         `json:"cart_item_id"`
+	ProductID     uint         `json:"product_id"`
+	VariantID     uint         `json:"variant_id"`
+	Code          string       `json:"code"` // CartWarning* тұрақтыларының бірі
+	Message       string       `json:"message"`
+	PreviousPrice *money.Money `json:"previous_price,omitempty"`
+	CurrentPrice  *money.Money `json:"current_price,omitempty"`
+	Available     *uint        `json:"available,omitempty"`
+}
+
+// CartSummary себеттің бағасы мен ескертулері. Жойылған өнімдердің жолдары жиынтыққа
+// кірмейді, ал қоры жетпейтін жолдар кіреді, бірақ оларды рәсімдеу сәтсіз болады.
+type CartSummary struct {
+	PriceBreakdown
+	Warnings []CartWarning `json:"warnings"`
+}
+
+// SummarizeCart пайдаланушының себетін Checkout есептейтіндей бағалайды: акциялар,
+// code берілсе купон, салық пен жеткізу. Бос себет қате емес, нөлдік есеп қайтарылады.
+func SummarizeCart(db *gorm.DB, settings Settings, userID uint, currency, code string) (*CartSummary, error) {
+	cartItems, err := loadCart(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(db, settings, cartItems, userID, currency, code)
+}
+
+func summarize(db *gorm.DB, settings Settings, cartItems []CartItem, userID uint, currency, code string) (*CartSummary, error) {
+	if currency == "" {
+		currency = settings.Currency
+	}
+	converter, err := LoadConverter(db, settings)
+	if err != nil {
+		return nil, err
+	}
+	lines, warnings, err := cartLines(converter, currency, cartItems)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown, err := priceCart(db, settings, converter, currency, lines, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	return &CartSummary{PriceBreakdown: *breakdown, Warnings: warnings}, nil
+}
+
+func loadCart(db *gorm.DB, userID uint) ([]CartItem, error) {
+	var cartItems []CartItem
+	err := db.Preload("Product").Preload("Variant").Where("user_id = ?", userID).Order("id").Find(&cartItems).Error
+	return cartItems, err
+}
+
+// priceCart жолдарға акцияларды және code берілсе купонды қолданады
+func priceCart(db *gorm.DB, settings Settings, converter *money.Converter, currency string, lines []PricedLine, userID uint, code string) (*PriceBreakdown, error) {
+	now := time.Now()
+	breakdown, err := newPriceBreakdown(converter, settings, currency, lines)
+	if err != nil {
+		return nil, err
+	}
+	if err := breakdown.applyPromotions(db, converter, now); err != nil {
+		return nil, err
+	}
+	if code != "" {
+		coupon, err := GetCouponByCode(db, code)
+		if err != nil {
+			return nil, err
+		}
+		if err := breakdown.applyCoupon(db, converter, coupon, userID, now); err != nil {
+			return nil, err
+		}
+	}
+	return breakdown, nil
+}
+
+// cartLines себет жолдарын currency валютасында бағалайды. Өнімі немесе нұсқасы жойылған
+// жолдар бағаланбайды; олар үшін, сондай-ақ бағасы өзгерген және қоры жетпейтін жолдар
+// үшін ескерту қайтарылады.
+func cartLines(converter *money.Converter, currency string, cartItems []CartItem) ([]PricedLine, []CartWarning, error) {
+	lines := make([]PricedLine, 0, len(cartItems))
+	warnings := []CartWarning{}
+	for _, item := range cartItems {
+		warning := CartWarning{CartItemID: item.ID, ProductID: item.ProductID, VariantID: item.VariantID}
+		if item.Product.ID == 0 || item.Variant.ID == 0 || item.Variant.ProductID != item.ProductID {
+			warning.Code = CartWarningUnavailable
+			warning.Message = "Product is no longer available"
+			warnings = append(warnings, warning)
+			continue
+		}
+
+		line, err := priceLine(converter, currency, item.Product, item.Variant, item.Quantity)
+		if err != nil {
+			return nil, nil, err
+		}
+		line.CartItemID = item.ID
+		lines = append(lines, line)
+
+		if item.AddedPrice != nil && *item.AddedPrice != item.Variant.UnitPrice(item.Product.Price) {
+			previous, err := converter.Convert(*item.AddedPrice, currency)
+			if err != nil {
+				return nil, nil, err
+			}
+			current := line.UnitPrice
+			priceChanged := warning
+			priceChanged.Code = CartWarningPriceChanged
+			priceChanged.Message = fmt.Sprintf("Price changed from %s to %s", previous, current)
+			priceChanged.PreviousPrice = &previous
+			priceChanged.CurrentPrice = &current
+			warnings = append(warnings, priceChanged)
+		}
+		if item.Quantity > item.Variant.Stock {
+			available := item.Variant.Stock
+			lowStock := warning
+			lowStock.Code = CartWarningLowStock
+			lowStock.Message = fmt.Sprintf("Only %d left in stock", available)
+			lowStock.Available = &available
+			warnings = append(warnings, lowStock)
+		}
+	}
+	return lines, warnings, nil
+}
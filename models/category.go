@@ -1,13 +1,36 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryCycle    = errors.New("category cannot be moved into its own subtree")
+	ErrCategoryNotEmpty = errors.New("category has subcategories or products")
+	ErrSlugTaken        = errors.New("category slug already exists")
+	ErrInvalidSlug      = errors.New("category slug is invalid")
 )
 
+// Category санаттар ағашының түйіні. ParentID nil болса, санат түбірде тұрады.
+// Slug бірегей және атаудан жасалады; атау өзгергенде сілтемелер бұзылмауы үшін сақталады.
 type Category struct {
-	ID   uint   `gorm:"primaryKey"`
-	Name string `gorm:"not null"`
-	URL  string `gorm:"not null"`
+	ID       uint   `gorm:"primaryKey"`
+	Name     string `gorm:"not null"`
+	Slug     string `gorm:"not null;unique"`
+	URL      string `gorm:"not null"`
+	ParentID *uint  `gorm:"index"`
+
+	// Children тек GetCategoryTree нәтижесінде толтырылады
+	Children []Category `gorm:"-" json:",omitempty"`
 }
 
 func GetAllCategories(db *gorm.DB) ([]Category, error) {
@@ -25,7 +48,274 @@ func GetCategoryByID(db *gorm.DB, id uint) (*Category, error) {
 	return &category, nil
 }
 
+// categorySlugAttempts атаудан жасалған slug-ты қатар жасалған санат алып қойса, неше рет қайта таңдау керек
+const categorySlugAttempts = 5
+
+// CreateCategory санат жасайды. Slug берілмесе, атаудан жасалып, қажет болса -2, -3 қосылады.
 func CreateCategory(db *gorm.DB, category *Category) (*Category, error) {
-	err := db.Create(&category).Error
-	return category, err
+	category.Children = nil
+	requested := category.Slug
+	var err error
+	for attempt := 0; attempt < categorySlugAttempts; attempt++ {
+		category.ID = 0
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if category.ParentID != nil {
+				if _, err := findCategory(tx, *category.ParentID); err != nil {
+					return err
+				}
+			}
+
+			slug, err := categorySlug(tx, requested, category.Name, 0)
+			if err != nil {
+				return err
+			}
+			category.Slug = slug
+			return tx.Create(category).Error
+		})
+		if !isUniqueViolation(err) {
+			return category, err
+		}
+		// Клиент берген slug-ты басқа сұрау бізден бұрын сақтады
+		if requested != "" {
+			break
+		}
+	}
+	return category, ErrSlugTaken
+}
+
+// UpdateCategory атауды, slug пен URL-ді өзгертеді. Ата-ана тек MoveCategory арқылы ауыстырылады.
+func UpdateCategory(db *gorm.DB, id uint, input *Category) (*Category, error) {
+	var category *Category
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if category, err = findCategory(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id); err != nil {
+			return err
+		}
+
+		if input.Name != "" {
+			category.Name = input.Name
+		}
+		if input.URL != "" {
+			category.URL = input.URL
+		}
+		if input.Slug != "" && input.Slug != category.Slug {
+			if category.Slug, err = categorySlug(tx, input.Slug, category.Name, category.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(category).Select("name", "slug", "url").Updates(category).Error
+	})
+	if isUniqueViolation(err) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// MoveCategory санатты барлық ұрпақтарымен бірге жаңа ата-анаға көшіреді.
+// parentID nil болса, санат түбірге шығады.
+func MoveCategory(db *gorm.DB, id uint, parentID *uint) (*Category, error) {
+	var category *Category
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Екі санатты бір уақытта бір-біріне көшіру цикл тудырмауы үшін көшірулер кезекпен орындалады
+		if err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		var err error
+		if category, err = findCategory(tx, id); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			if _, err := findCategory(tx, *parentID); err != nil {
+				return err
+			}
+			var inSubtree int64
+			if err := tx.Model(&Category{}).Where("id = ? AND id IN (?)", *parentID, CategorySubtree(tx, id)).Count(&inSubtree).Error; err != nil {
+				return err
+			}
+			if inSubtree > 0 {
+				return ErrCategoryCycle
+			}
+		}
+
+		category.ParentID = parentID
+		return tx.Model(category).Update("parent_id", parentID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// DeleteCategory ішкі санаттары мен өнімдері жоқ санатты жояды
+func DeleteCategory(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		category, err := findCategory(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		var products int64
+		if err := tx.Model(&Product{}).Where("category_id = ?", id).Count(&products).Error; err != nil {
+			return err
+		}
+		if children > 0 || products > 0 {
+			return ErrCategoryNotEmpty
+		}
+		return tx.Delete(category).Error
+	})
+}
+
+// GetCategoryTree барлық санаттарды ағаш түрінде қайтарады. Бір деңгейдегі санаттар атауы бойынша сұрыпталады.
+func GetCategoryTree(db *gorm.DB) ([]Category, error) {
+	var categories []Category
+	if err := db.Order("name, id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	children := map[uint][]Category{}
+	for _, category := range categories {
+		var parentID uint
+		if category.ParentID != nil {
+			parentID = *category.ParentID
+		}
+		children[parentID] = append(children[parentID], category)
+	}
+
+	var build func(parentID uint) []Category
+	build = func(parentID uint) []Category {
+		nodes := children[parentID]
+		for i := range nodes {
+			nodes[i].Children = build(nodes[i].ID)
+		}
+		return nodes
+	}
+
+	tree := build(0)
+	if tree == nil {
+		tree = []Category{}
+	}
+	return tree, nil
+}
+
+// GetCategoryBreadcrumbs түбірден бастап санаттың өзіне дейінгі жолды қайтарады
+func GetCategoryBreadcrumbs(db *gorm.DB, id uint) ([]Category, error) {
+	var path []Category
+	err := db.Raw(`WITH RECURSIVE path AS (
+		SELECT id, name, slug, url, parent_id, 0 AS depth FROM categories WHERE id = ?
+		UNION ALL
+		SELECT c.id, c.name, c.slug, c.url, c.parent_id, path.depth + 1
+		FROM categories c JOIN path ON c.id = path.parent_id
+	)
+	SELECT id, name, slug, url, parent_id FROM path ORDER BY depth DESC`, id).Scan(&path).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, ErrCategoryNotFound
+	}
+	return path, nil
+}
+
+// CategorySubtree санаттың өзі мен барлық ұрпақтарының id-лерін беретін ішкі сұрау,
+// мысалы: Where("category_id IN (?)", CategorySubtree(db, id))
+func CategorySubtree(db *gorm.DB, id uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Raw(`WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE id = ?
+		UNION ALL
+		SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+	)
+	SELECT id FROM subtree`, id)
+}
+
+func findCategory(db *gorm.DB, id uint) (*Category, error) {
+	var category Category
+	if err := db.First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// categorySlug санаттың slug-ын анықтайды. Клиент берген slug дұрыс пішімде және бос болуы керек,
+// ал атаудан жасалған slug бос болмаса, соңына сан қосылады.
+func categorySlug(tx *gorm.DB, requested, name string, exceptID uint) (string, error) {
+	if requested != "" {
+		slug := Slugify(requested)
+		if slug == "" || slug != strings.ToLower(requested) {
+			return "", ErrInvalidSlug
+		}
+		taken, err := slugTaken(tx, slug, exceptID)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", ErrSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := Slugify(name)
+	if base == "" {
+		base = "category"
+	}
+	slug := base
+	for n := 2; ; n++ {
+		taken, err := slugTaken(tx, slug, exceptID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+func slugTaken(tx *gorm.DB, slug string, exceptID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&Category{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// isUniqueViolation қатенің бірегейлік шектеуін бұзудан (23505) туындағанын тексереді
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// slugTranslit кирилл (соның ішінде қазақ) әріптерін латынға ауыстырады
+var slugTranslit = map[rune]string{
+	'а': "a", 'ә': "a", 'б': "b", 'в': "v", 'г': "g", 'ғ': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'қ': "q", 'л': "l", 'м': "m", 'н': "n",
+	'ң': "n", 'о': "o", 'ө': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ұ': "u",
+	'ү': "u", 'ф': "f", 'х': "h", 'һ': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'і': "i", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify мәтінді URL-ге жарамды slug-қа айналдырады: "Ерлер киімі" -> "erler-kiimi"
+func Slugify(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if latin, ok := slugTranslit[r]; ok {
+			b.WriteString(latin)
+		} else if r < unicode.MaxASCII {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(slugSeparators.ReplaceAllString(b.String(), "-"), "-")
 }
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCartEmpty          = errors.New("cart is empty")
+	ErrProductUnavailable = errors.New("product is no longer available")
+	ErrInsufficientStock  = errors.New("not enough stock")
+)
+
+// StockError қай өнімге (нұсқаға) қор жетпейтінін көрсетеді
+type StockError struct {
+	ProductID uint
+	VariantID uint
+	Requested uint
+	Available uint
+}
+
+func (e *StockError) Error() string {
+	if e.VariantID != 0 {
+		return fmt.Sprintf("not enough stock for product %d variant %d: requested %d, available %d", e.ProductID, e.VariantID, e.Requested, e.Available)
+	}
+	return fmt.Sprintf("not enough stock for product %d: requested %d, available %d", e.ProductID, e.Requested, e.Available)
+}
+
+func (e *StockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// CheckoutOptions клиент таңдайтын рәсімдеу параметрлері
+type CheckoutOptions struct {
+	City       string // берілсе, сол қаладағы қоймалар бірінші
+	Currency   string // бос болса, негізгі валюта
+	CouponCode string
+}
+
+// Checkout пайдаланушының себетін бір транзакцияда тапсырысқа айналдырады:
+// себетті құлыптайды, placeOrder арқылы тапсырыс жасайды және себетті тазалайды.
+// Кез келген қате болса, бәрі кері қайтарылады.
+func Checkout(db *gorm.DB, settings Settings, userID uint, options CheckoutOptions) (*Order, error) {
+	var order *Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Бір себетті қатар екі рет рәсімдеуге жол бермеу
+		var cartItems []CartItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).Order("id").Find(&cartItems).Error; err != nil {
+			return err
+		}
+		if len(cartItems) == 0 {
+			return ErrCartEmpty
+		}
+
+		lines := make([]orderLine, 0, len(cartItems))
+		for _, item := range cartItems {
+			lines = append(lines, orderLine{CartItemID: item.ID, ProductID: item.ProductID, VariantID: item.VariantID, Quantity: item.Quantity})
+		}
+		var err error
+		if order, err = placeOrder(tx, settings, userID, lines, options, "checkout"); err != nil {
+			return err
+		}
+
+		// Тапсырыс берілген себет туралы еске салу жіберілмейді
+		if err := suppressAbandonedCarts(tx, userID, SuppressedCheckedOut); err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&CartItem{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loadPlacedOrder(db, order.ID)
+}
+
+// orderLine тапсырысқа айналатын жол: себет жолы немесе POST /orders арқылы берілген жол
+type orderLine struct {
+	CartItemID uint
+	ProductID  uint
+	VariantID  uint
+	Quantity   uint
+}
+
+// placeOrder жолдардан тапсырыс жасайды: өнімдер мен нұсқаларды құлыптайды, жолдарды
+// сервердегі бағалармен options.Currency валютасында бағалайды (әр бірлік бағасы қазіргі
+// бағаммен түрлендіріледі, ал қолданылған бағам тапсырысқа жазылады), алдымен жарамды
+// акцияларды, содан кейін купонды қолданады, жолдарды қоймаларға бөліп, қорды резервтейді.
+// Жеңілдіктер жолдарға бөлініп, OrderItem-ге жазылады, ал купонның қолданысы тапсырысқа
+// жазылады. Транзакция ішінде шақырылуы керек.
+func placeOrder(tx *gorm.DB, settings Settings, userID uint, lines []orderLine, options CheckoutOptions, note string) (*Order, error) {
+	currency := options.Currency
+	if currency == "" {
+		currency = settings.Currency
+	}
+	converter, err := LoadConverter(tx, settings)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := converter.Rate(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := map[uint]uint{}
+	var productIDs, variantIDs []uint
+	seenProducts := map[uint]bool{}
+	for _, line := range lines {
+		if _, seen := quantities[line.VariantID]; !seen {
+			variantIDs = append(variantIDs, line.VariantID)
+		}
+		quantities[line.VariantID] += line.Quantity
+		if !seenProducts[line.ProductID] {
+			seenProducts[line.ProductID] = true
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+	productsByID, variantsByID, err := lockStockRows(tx, productIDs, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if _, ok := productsByID[line.ProductID]; !ok {
+			return nil, fmt.Errorf("%w: product %d", ErrProductUnavailable, line.ProductID)
+		}
+		variant, ok := variantsByID[line.VariantID]
+		if !ok || variant.ProductID != line.ProductID {
+			return nil, fmt.Errorf("%w: product %d variant %d", ErrProductUnavailable, line.ProductID, line.VariantID)
+		}
+		if quantities[variant.ID] > variant.Stock {
+			return nil, &StockError{ProductID: line.ProductID, VariantID: variant.ID, Requested: quantities[variant.ID], Available: variant.Stock}
+		}
+	}
+
+	priced := make([]PricedLine, 0, len(lines))
+	for _, line := range lines {
+		pricedLine, err := priceLine(converter, currency, productsByID[line.ProductID], variantsByID[line.VariantID], line.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		pricedLine.CartItemID = line.CartItemID
+		priced = append(priced, pricedLine)
+	}
+	breakdown, err := newPriceBreakdown(converter, settings, currency, priced)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := breakdown.applyPromotions(tx, converter, now); err != nil {
+		return nil, err
+	}
+	if options.CouponCode != "" {
+		// Купон жолы құлыпталады, сондықтан қолданыс шектерін қатар тапсырыстар аса алмайды
+		coupon, err := GetCouponByCode(tx.Clauses(clause.Locking{Strength: "UPDATE"}), options.CouponCode)
+		if err != nil {
+			return nil, err
+		}
+		if err := breakdown.applyCoupon(tx, converter, coupon, userID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	order := Order{
+		UserID:       userID,
+		OrderDate:    now,
+		Status:       OrderStatusPending,
+		Subtotal:     breakdown.Subtotal,
+		Discount:     breakdown.Discount.Add(breakdown.ShippingDiscount),
+		Shipping:     breakdown.Shipping,
+		Tax:          breakdown.Tax,
+		TaxIncluded:  breakdown.TaxIncluded,
+		Total:        breakdown.Total,
+		ExchangeRate: rate.FloatString(8),
+	}
+	items := make([]OrderItem, 0, len(breakdown.Lines))
+	for _, line := range breakdown.Lines {
+		items = append(items, OrderItem{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			Price:     line.UnitPrice,
+			Discount:  line.Discount,
+			Discounts: append([]LineDiscount{}, line.Discounts...),
+		})
+	}
+
+	// Жолдарды қоймаларға бөлу
+	if order.OrderItems, err = allocateOrderItems(tx, items, options.City); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		return nil, err
+	}
+	if breakdown.Coupon != nil {
+		redemption := CouponRedemption{
+			CouponID: breakdown.Coupon.ID,
+			OrderID:  order.ID,
+			UserID:   userID,
+			Code:     breakdown.Coupon.Code,
+			Discount: breakdown.Coupon.Discount,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := recordOrderStatus(tx, order.ID, "", OrderStatusPending, userID, note); err != nil {
+		return nil, err
+	}
+
+	// Қор тапсырыс төленгенше немесе резерв мерзімі біткенше ұсталады
+	if err := reserveStock(tx, settings, order.ID, order.OrderItems); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func loadPlacedOrder(db *gorm.DB, orderID uint) (*Order, error) {
+	var order Order
+	err := db.Preload("User").Preload("OrderItems.Product").Preload("OrderItems.Product.Category").Preload("OrderItems.Variant").
+		Preload("CouponRedemptions").First(&order, orderID).Error
+	return &order, err
+}
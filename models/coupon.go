@@ -0,0 +1,298 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	CouponPercentage   = "percentage"
+	CouponFixed        = "fixed"
+	CouponFreeShipping = "free_shipping"
+)
+
+var (
+	ErrCouponNotFound      = errors.New("coupon not found")
+	ErrCouponInvalid       = errors.New("invalid coupon")
+	ErrCouponCodeTaken     = errors.New("coupon code already exists")
+	ErrCouponInUse         = errors.New("coupon has redemptions")
+	ErrCouponNotApplicable = errors.New("coupon cannot be applied")
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// CouponError купонды неге қолдануға болмайтынын клиентке түсінікті етіп көрсетеді
+type CouponError struct {
+	Code   string
+	Reason string
+}
+
+func (e *CouponError) Error() string {
+	return fmt.Sprintf("coupon %s cannot be applied: %s", e.Code, e.Reason)
+}
+
+func (e *CouponError) Is(target error) bool {
+	return target == ErrCouponNotApplicable
+}
+
+// Coupon жеңілдік коды. Сомалар негізгі валютада беріледі және тапсырыс валютасына түрлендіріледі.
+// ProductIDs мен CategoryIDs бос болса, купон барлық өнімдерге қолданылады; әйтпесе тек
+// көрсетілген өнімдерге және көрсетілген категориялардағы (ішкі категориялармен бірге) өнімдерге.
+type Coupon struct {
+	ID           uint         `gorm:"primaryKey"`
+	Code         string       `gorm:"not null;unique"`                    // әрқашан бас әріппен сақталады
+	Type         string       `gorm:"not null"`                           // Coupon* тұрақтыларының бірі
+	Percent      int          `gorm:"not null"`                           // percentage купоны үшін 1–100
+	Amount       *money.Money `gorm:"embedded;embeddedPrefix:amount_"`    // fixed купоны үшін
+	MinOrder     *money.Money `gorm:"embedded;embeddedPrefix:min_order_"` // тауарлар сомасының шегі
+	ProductIDs   []uint       `gorm:"type:jsonb;serializer:json;not null"`
+	CategoryIDs  []uint       `gorm:"type:jsonb;serializer:json;not null"`
+	UsageLimit   *int         // барлық пайдаланушылар үшін жалпы шек
+	PerUserLimit *int
+	StartsAt     *time.Time
+	EndsAt       *time.Time
+	Active       *bool `gorm:"not null;default:true"` // берілмесе, купон белсенді
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// CouponRedemption купонның тапсырыста қолданылғанын сақтайды. Discount тапсырыс валютасында,
+// жеткізу жеңілдігімен бірге. Бас тартылған тапсырыстар шекке есептелмейді.
+type CouponRedemption struct {
+	ID        uint        `gorm:"primaryKey"`
+	CouponID  uint        `gorm:"not null;index"`
+	OrderID   uint        `gorm:"not null;index"`
+	UserID    uint        `gorm:"not null"`
+	Code      string      `gorm:"not null"`
+	Discount  money.Money `gorm:"embedded;embeddedPrefix:discount_"`
+	CreatedAt time.Time
+}
+
+func GetCouponByID(db *gorm.DB, id uint) (*Coupon, error) {
+	var coupon Coupon
+	if err := db.First(&coupon, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetCouponByCode кодты бас әріпке келтіріп іздейді
+func GetCouponByCode(db *gorm.DB, code string) (*Coupon, error) {
+	var coupon Coupon
+	err := db.Where("code = ?", normalizeCouponCode(code)).First(&coupon).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCouponNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func CreateCoupon(db *gorm.DB, settings Settings, coupon *Coupon) (*Coupon, error) {
+	if err := checkCoupon(db, settings, coupon); err != nil {
+		return nil, err
+	}
+	taken, err := couponCodeTaken(db, coupon.Code, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrCouponCodeTaken
+	}
+	coupon.ID = 0
+	if err := db.Create(coupon).Error; err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon купонның барлық өрістерін ауыстырады; Active берілмесе, ол өзгермейді
+func UpdateCoupon(db *gorm.DB, settings Settings, id uint, coupon *Coupon) (*Coupon, error) {
+	current, err := GetCouponByID(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCoupon(db, settings, coupon); err != nil {
+		return nil, err
+	}
+	taken, err := couponCodeTaken(db, coupon.Code, id)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrCouponCodeTaken
+	}
+	coupon.ID = current.ID
+	coupon.CreatedAt = current.CreatedAt
+	if coupon.Active == nil {
+		coupon.Active = current.Active
+	}
+	if err := db.Save(coupon).Error; err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+// DeleteCoupon қолданылмаған купонды жояды. Қолданылған купон есептер үшін сақталады,
+// оны тек өшіруге (Active = false) болады.
+func DeleteCoupon(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		coupon, err := GetCouponByID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+		var redemptions int64
+		if err := tx.Model(&CouponRedemption{}).Where("coupon_id = ?", id).Count(&redemptions).Error; err != nil {
+			return err
+		}
+		if redemptions > 0 {
+			return ErrCouponInUse
+		}
+		return tx.Delete(coupon).Error
+	})
+}
+
+// checkCoupon әкімші берген купонды тексереді және кодты қалыпқа келтіреді
+func checkCoupon(db *gorm.DB, settings Settings, coupon *Coupon) error {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	if !couponCodePattern.MatchString(coupon.Code) {
+		return fmt.Errorf("%w: code must be 3-32 letters, digits, '-' or '_'", ErrCouponInvalid)
+	}
+
+	switch coupon.Type {
+	case CouponPercentage:
+		if coupon.Percent < 1 || coupon.Percent > 100 {
+			return fmt.Errorf("%w: percent must be between 1 and 100", ErrCouponInvalid)
+		}
+		coupon.Amount = nil
+	case CouponFixed:
+		if coupon.Amount != nil {
+			if err := checkPrice(coupon.Amount, settings.Currency); err != nil {
+				return err
+			}
+		}
+		if coupon.Amount == nil || coupon.Amount.Amount <= 0 {
+			return fmt.Errorf("%w: fixed coupons need a positive amount", ErrCouponInvalid)
+		}
+		coupon.Percent = 0
+	case CouponFreeShipping:
+		coupon.Percent = 0
+		coupon.Amount = nil
+	default:
+		return fmt.Errorf("%w: type must be %s, %s or %s", ErrCouponInvalid, CouponPercentage, CouponFixed, CouponFreeShipping)
+	}
+
+	if coupon.MinOrder != nil {
+		if err := checkPrice(coupon.MinOrder, settings.Currency); err != nil {
+			return err
+		}
+	}
+	if (coupon.UsageLimit != nil && *coupon.UsageLimit <= 0) || (coupon.PerUserLimit != nil && *coupon.PerUserLimit <= 0) {
+		return fmt.Errorf("%w: usage limits must be positive", ErrCouponInvalid)
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrCouponInvalid)
+	}
+
+	if coupon.ProductIDs == nil {
+		coupon.ProductIDs = []uint{}
+	}
+	if coupon.CategoryIDs == nil {
+		coupon.CategoryIDs = []uint{}
+	}
+	return checkScope(db, coupon.ProductIDs, coupon.CategoryIDs, ErrCouponInvalid)
+}
+
+// checkScope өнімдер мен категориялардың бар екенін тексереді; қате invalid арқылы оралады
+func checkScope(db *gorm.DB, productIDs, categoryIDs []uint, invalid error) error {
+	if err := checkIDsExist(db, &Product{}, productIDs, "product", invalid); err != nil {
+		return err
+	}
+	return checkIDsExist(db, &Category{}, categoryIDs, "category", invalid)
+}
+
+func checkIDsExist(db *gorm.DB, model interface{}, ids []uint, name string, invalid error) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var found []uint
+	if err := db.Model(model).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+		return err
+	}
+	existing := make(map[uint]bool, len(found))
+	for _, id := range found {
+		existing[id] = true
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			return fmt.Errorf("%w: %s %d not found", invalid, name, id)
+		}
+	}
+	return nil
+}
+
+func couponCodeTaken(db *gorm.DB, code string, exceptID uint) (bool, error) {
+	var count int64
+	err := db.Model(&Coupon{}).Where("code = ? AND id <> ?", code, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// checkCouponUsable купонның белсенді, мерзімі жарамды және шектері толмағанын тексереді
+func checkCouponUsable(db *gorm.DB, coupon *Coupon, userID uint, now time.Time) error {
+	switch {
+	case coupon.Active != nil && !*coupon.Active:
+		return &CouponError{Code: coupon.Code, Reason: "coupon is not active"}
+	case coupon.StartsAt != nil && now.Before(*coupon.StartsAt):
+		return &CouponError{Code: coupon.Code, Reason: "coupon is not valid yet"}
+	case coupon.EndsAt != nil && !now.Before(*coupon.EndsAt):
+		return &CouponError{Code: coupon.Code, Reason: "coupon has expired"}
+	}
+
+	if coupon.UsageLimit != nil {
+		used, err := countRedemptions(db, coupon.ID, 0)
+		if err != nil {
+			return err
+		}
+		if used >= int64(*coupon.UsageLimit) {
+			return &CouponError{Code: coupon.Code, Reason: "coupon usage limit reached"}
+		}
+	}
+	if coupon.PerUserLimit != nil {
+		used, err := countRedemptions(db, coupon.ID, userID)
+		if err != nil {
+			return err
+		}
+		if used >= int64(*coupon.PerUserLimit) {
+			return &CouponError{Code: coupon.Code, Reason: "you have already used this coupon"}
+		}
+	}
+	return nil
+}
+
+// countRedemptions бас тартылмаған тапсырыстардағы қолданыстарды санайды; userID 0 болса, барлығын
+func countRedemptions(db *gorm.DB, couponID, userID uint) (int64, error) {
+	query := db.Model(&CouponRedemption{}).
+		Joins("JOIN orders ON orders.id = coupon_redemptions.order_id").
+		Where("coupon_redemptions.coupon_id = ? AND orders.status <> ?", couponID, OrderStatusCancelled)
+	if userID != 0 {
+		query = query.Where("coupon_redemptions.user_id = ?", userID)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"NomadShop/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB NOMADSHOP_TEST_DB_DSN базасын миграциялап, тест соңында кері қайтарылатын
+// транзакцияны береді. Айнымалы берілмесе, базаны қажет ететін тест өткізіліп жіберіледі.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("NOMADSHOP_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("NOMADSHOP_TEST_DB_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatal(tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// testProduct бір әдепкі нұсқасы және stock қоры бар өнім жасайды
+func testProduct(t *testing.T, db *gorm.DB, stock uint) *Product {
+	t.Helper()
+	category, err := CreateCategory(db, &Category{Name: "Test category"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	product, err := CreateProduct(db, DefaultSettings, &Product{Name: "Test product", CategoryID: category.ID, Stock: stock}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return product
+}
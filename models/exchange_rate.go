@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	ErrBaseCurrencyRate     = errors.New("base currency has a fixed rate of 1")
+	ErrInvalidRateFile      = errors.New("invalid exchange rate file")
+)
+
+// ExchangeRate валютаның бір бірлігі негізгі валютаның (Settings.Currency) қанша бірлігіне тең
+// екенін сақтайды, мысалы USD: 487.50. Бағамдарды әкімші қолмен немесе CSV арқылы жаңартады.
+type ExchangeRate struct {
+	ID        uint      `gorm:"primaryKey"`
+	Currency  string    `gorm:"type:char(3);not null;unique"`
+	Rate      string    `gorm:"type:numeric(20,8);not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+func GetExchangeRates(db *gorm.DB) ([]ExchangeRate, error) {
+	rates := []ExchangeRate{}
+	err := db.Order("currency").Find(&rates).Error
+	return rates, err
+}
+
+// SetExchangeRate валютаның бағамын қосады немесе жаңартады
+func SetExchangeRate(db *gorm.DB, settings Settings, currency, rate string) (*ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == settings.Currency {
+		return nil, ErrBaseCurrencyRate
+	}
+	if _, err := money.Exponent(currency); err != nil {
+		return nil, err
+	}
+	parsed, err := money.ParseRate(rate)
+	if err != nil {
+		return nil, err
+	}
+	// numeric(20,8) артық таңбаларды үнсіз дөңгелектейтін еді
+	normalized := parsed.FloatString(8)
+	if check, _ := new(big.Rat).SetString(normalized); check.Cmp(parsed) != 0 {
+		return nil, fmt.Errorf("%w: at most 8 decimal places, got %q", money.ErrInvalidRate, rate)
+	}
+
+	exchangeRate := ExchangeRate{Currency: currency, Rate: normalized, UpdatedAt: time.Now()}
+	err = db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_at"}),
+	}).Create(&exchangeRate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &exchangeRate, nil
+}
+
+func DeleteExchangeRate(db *gorm.DB, currency string) error {
+	result := db.Where("currency = ?", strings.ToUpper(currency)).Delete(&ExchangeRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
+
+// ImportExchangeRates "currency,rate" жолдарынан тұратын CSV файлын бір транзакцияда жүктейді.
+// Бірінші жол тақырып болуы мүмкін. Файлда жоқ валюталардың бағамы өзгермейді.
+func ImportExchangeRates(db *gorm.DB, settings Settings, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	imported := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for first := true; ; first = false {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidRateFile, err)
+			}
+			if first && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+				continue
+			}
+			if _, err := SetExchangeRate(tx, settings, record[0], record[1]); err != nil {
+				line, _ := reader.FieldPos(0)
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			imported++
+		}
+		if imported == 0 {
+			return fmt.Errorf("%w: no rates found", ErrInvalidRateFile)
+		}
+		return nil
+	})
+	return imported, err
+}
+
+// LoadConverter барлық бағамдарды жүктеп, түрлендіргіш жасайды
+func LoadConverter(db *gorm.DB, settings Settings) (*money.Converter, error) {
+	rates, err := GetExchangeRates(db)
+	if err != nil {
+		return nil, err
+	}
+	converter := &money.Converter{
+		Base:     settings.Currency,
+		Rates:    make(map[string]*big.Rat, len(rates)),
+		Rounding: settings.Rounding,
+	}
+	for _, rate := range rates {
+		parsed, err := money.ParseRate(rate.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("exchange rate for %s: %w", rate.Currency, err)
+		}
+		converter.Rates[strings.TrimSpace(rate.Currency)] = parsed
+	}
+	return converter, nil
+}
@@ -4,11 +4,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// FavoriteItem таңдаулы өнім. VariantID бос болса, өнім тұтастай таңдалған.
 type FavoriteItem struct {
-	ID        uint    `gorm:"primaryKey"`
-	UserID    uint    `gorm:"not null"`
-	ProductID uint    `gorm:"not null"`
-	Product   Product `gorm:"foreignKey:ProductID"`
+	ID        uint            `gorm:"primaryKey"`
+	UserID    uint            `gorm:"not null"`
+	ProductID uint            `gorm:"not null"`
+	VariantID *uint           `json:",omitempty"`
+	Product   Product         `gorm:"foreignKey:ProductID"`
+	Variant   *ProductVariant `gorm:"foreignKey:VariantID" json:",omitempty"`
 }
 
 func AddToFavorites(db *gorm.DB, favoriteItem *FavoriteItem) (*FavoriteItem, error) {
@@ -18,13 +21,13 @@ func AddToFavorites(db *gorm.DB, favoriteItem *FavoriteItem) (*FavoriteItem, err
 
 func GetFavoriteItems(db *gorm.DB, userID uint) ([]FavoriteItem, error) {
 	var favoriteItems []FavoriteItem
-	err := db.Preload("Product").Preload("Product.Category").Where("user_id = ?", userID).Find(&favoriteItems).Error
+	err := db.Preload("Product").Preload("Product.Category").Preload("Variant").Where("user_id = ?", userID).Find(&favoriteItems).Error
 	return favoriteItems, err
 }
 
 func GetFavoriteItemByID(db *gorm.DB, id uint) (*FavoriteItem, error) {
 	var favoriteItem FavoriteItem
-	err := db.Preload("Product").Preload("Product.Category").First(&favoriteItem, id).Error
+	err := db.Preload("Product").Preload("Product.Category").Preload("Variant").First(&favoriteItem, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGuestCartNotFound     = errors.New("guest cart not found")
+	ErrGuestCartItemNotFound = errors.New("guest cart item not found")
+	ErrAlreadyInCart         = errors.New("product already in cart")
+)
+
+const (
+	MergeAlreadyInCart = "already_in_cart"
+	MergeLowStock      = "insufficient_stock"
+	MergeUnavailable   = "product_unavailable"
+)
+
+// GuestCart кірмеген келушінің себеті. Клиент токеннің өзін сақтайды, базада тек хэші.
+// UpdatedAt әр қолданыста жаңарады; Settings.GuestCartTTL өтсе, себет жойылады.
+type GuestCart struct {
+	ID        uint            `gorm:"primaryKey"`
+	TokenHash string          `gorm:"not null;uniqueIndex" json:"-"`
+	Items     []GuestCartItem `gorm:"foreignKey:GuestCartID;references:ID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GuestCartItem қонақ себетінің жолы, CartItem-мен бірдей ережелер бойынша
+type GuestCartItem struct {
+	ID          uint           `gorm:"primaryKey"`
+	GuestCartID uint           `gorm:"not null"`
+	ProductID   uint           `gorm:"not null"`
+	VariantID   uint           `gorm:"not null"`
+	Quantity    uint           `gorm:"not null"`
+	AddedPrice  *money.Money   `gorm:"embedded;embeddedPrefix:added_price_"`
+	Product     Product        `gorm:"foreignKey:ProductID;references:ID"`
+	Variant     ProductVariant `gorm:"foreignKey:VariantID;references:ID"`
+}
+
+// CartMerge қонақ себетін пайдаланушы себетіне біріктіру нәтижесі
+type CartMerge struct {
+	Merged      int               `json:"merged"` // қосылған немесе саны өзгерген жолдар
+	Adjustments []MergeAdjustment `json:"adjustments"`
+}
+
+// MergeAdjustment қонақ себетінің жолы неге сұралғандай көшірілмегенін көрсетеді
+type MergeAdjustment struct {
+	ProductID uint   `json:"product_id"`
+	VariantID uint   `json:"variant_id"`
+	Requested uint   `json:"requested"`
+	Quantity  uint   `json:"quantity"` // пайдаланушы себетіндегі соңғы саны
+	Reason    string `json:"reason"`   // Merge* тұрақтыларының бірі
+}
+
+func CreateGuestCart(db *gorm.DB, tokenHash string) (*GuestCart, error) {
+	cart := GuestCart{TokenHash: tokenHash, Items: []GuestCartItem{}}
+	if err := db.Create(&cart).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// GetGuestCart мерзімі өтпеген себетті жолдарымен бірге жүктейді және соңғы қолданыс уақытын жаңартады
+func GetGuestCart(db *gorm.DB, settings Settings, tokenHash string) (*GuestCart, error) {
+	var cart GuestCart
+	err := db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.Product").Preload("Items.Product.Category").Preload("Items.Variant").
+		Where("token_hash = ? AND updated_at > ?", tokenHash, time.Now().Add(-settings.GuestCartTTL)).
+		First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGuestCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(&cart).UpdateColumn("updated_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// AddGuestCartItem себетке жол қосады. Нұсқа берілмесе, әдепкі нұсқа алынады;
+// бір нұсқа себетте екі рет болмайды.
+func AddGuestCartItem(db *gorm.DB, cart *GuestCart, item *GuestCartItem) (*GuestCartItem, error) {
+	var product Product
+	if err := db.First(&product, item.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	variant, err := ResolveVariant(db, product.ID, item.VariantID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Quantity > variant.Stock {
+		return nil, &StockError{ProductID: product.ID, VariantID: variant.ID, Requested: item.Quantity, Available: variant.Stock}
+	}
+
+	addedPrice := variant.UnitPrice(product.Price)
+	created := GuestCartItem{
+		GuestCartID: cart.ID,
+		ProductID:   product.ID,
+		VariantID:   variant.ID,
+		Quantity:    item.Quantity,
+		AddedPrice:  &addedPrice,
+	}
+	// Қатар келген екі сұраудың біреуі ғана жол қосады: бірегей индекс (guest_cart_id, variant_id)
+	result := db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "guest_cart_id"}, {Name: "variant_id"}}, DoNothing: true}).
+		Create(&created)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyInCart
+	}
+	err = db.Preload("Product").Preload("Product.Category").Preload("Variant").First(&created, created.ID).Error
+	return &created, err
+}
+
+// UpdateGuestCartItem жолдың санын өзгертеді
+func UpdateGuestCartItem(db *gorm.DB, cart *GuestCart, itemID uint, quantity uint) (*GuestCartItem, error) {
+	var item GuestCartItem
+	err := db.Preload("Product").Preload("Variant").Where("guest_cart_id = ?", cart.ID).First(&item, itemID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGuestCartItemNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if quantity > item.Variant.Stock {
+		return nil, &StockError{ProductID: item.ProductID, VariantID: item.VariantID, Requested: quantity, Available: item.Variant.Stock}
+	}
+	item.Quantity = quantity
+	if err := db.Model(&item).Update("quantity", quantity).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func DeleteGuestCartItem(db *gorm.DB, cart *GuestCart, itemID uint) error {
+	result := db.Where("guest_cart_id = ?", cart.ID).Delete(&GuestCartItem{}, itemID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGuestCartItemNotFound
+	}
+	return nil
+}
+
+// SummarizeGuestCart қонақ себетін SummarizeCart сияқты бағалайды. Купондар тек
+// кірген пайдаланушыларға қолданылады.
+func SummarizeGuestCart(db *gorm.DB, settings Settings, cart *GuestCart, currency string) (*CartSummary, error) {
+	cartItems := make([]CartItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		cartItems = append(cartItems, CartItem{
+			ID:         item.ID,
+			ProductID:  item.ProductID,
+			VariantID:  item.VariantID,
+			Quantity:   item.Quantity,
+			AddedPrice: item.AddedPrice,
+			Product:    item.Product,
+			Variant:    item.Variant,
+		})
+	}
+	return summarize(db, settings, cartItems, 0, currency, "")
+}
+
+// MergeGuestCart қонақ себетін пайдаланушы себетіне көшіріп, қонақ себетін жояды.
+// Бір нұсқа себетте екі рет болмайды: нұсқа пайдаланушы себетінде бар болса, екі санның
+// үлкені алынады. Сандар қордан аспайды, сондықтан қоры таусылған нұсқаның пайдаланушы
+// себетіндегі жолы жойылады, ал жойылған өнімдер мен қоры жоқ жолдар көшірілмейді.
+// Барлық осындай өзгерістер Adjustments ішінде қайтарылады.
+func MergeGuestCart(db *gorm.DB, settings Settings, tokenHash string, userID uint) (*CartMerge, error) {
+	merge := CartMerge{Adjustments: []MergeAdjustment{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Бір себетті қатар екі кіру біріктірмеуі үшін
+		var cart GuestCart
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND updated_at > ?", tokenHash, time.Now().Add(-settings.GuestCartTTL)).
+			First(&cart).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrGuestCartNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var guestItems []GuestCartItem
+		if err := tx.Preload("Variant").Where("guest_cart_id = ?", cart.ID).Order("id").Find(&guestItems).Error; err != nil {
+			return err
+		}
+		var userItems []CartItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).Order("id").Find(&userItems).Error; err != nil {
+			return err
+		}
+		existing := make(map[uint]*CartItem, len(userItems))
+		for i := range userItems {
+			existing[userItems[i].VariantID] = &userItems[i]
+		}
+
+		for _, item := range guestItems {
+			current := existing[item.VariantID]
+			quantity, adjustment := mergeLine(item, current)
+			if adjustment != nil {
+				merge.Adjustments = append(merge.Adjustments, *adjustment)
+			}
+
+			if current != nil {
+				switch quantity {
+				case current.Quantity:
+				case 0:
+					if err := tx.Delete(current).Error; err != nil {
+						return err
+					}
+				default:
+					if err := tx.Model(current).Update("quantity", quantity).Error; err != nil {
+						return err
+					}
+					merge.Merged++
+				}
+				continue
+			}
+			if quantity == 0 {
+				continue
+			}
+			cartItem := CartItem{
+				UserID:     userID,
+				ProductID:  item.ProductID,
+				VariantID:  item.VariantID,
+				Quantity:   quantity,
+				AddedPrice: item.AddedPrice,
+			}
+			if err := tx.Omit(clause.Associations).Create(&cartItem).Error; err != nil {
+				return err
+			}
+			merge.Merged++
+		}
+
+		// Жолдар ON DELETE CASCADE арқылы жойылады
+		return tx.Delete(&cart).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
+
+// mergeLine қонақ себетінің жолы пайдаланушы себетінде қандай сан болатынын анықтайды.
+// current — пайдаланушы себетіндегі сол нұсқаның жолы, жоқ болса nil. 0 қайтарылса, жол
+// көшірілмейді, ал пайдаланушының бар жолы жойылады. Жол сұралғандай көшірілмесе,
+// себебі adjustment-те беріледі.
+func mergeLine(item GuestCartItem, current *CartItem) (uint, *MergeAdjustment) {
+	adjustment := &MergeAdjustment{ProductID: item.ProductID, VariantID: item.VariantID, Requested: item.Quantity}
+	if item.Variant.ID == 0 || item.Variant.ProductID != item.ProductID {
+		// Пайдаланушының өз жолы өзгеріссіз қалады
+		if current != nil {
+			adjustment.Quantity = current.Quantity
+		}
+		adjustment.Reason = MergeUnavailable
+		return adjustment.Quantity, adjustment
+	}
+
+	wanted := item.Quantity
+	if current != nil {
+		// Пайдаланушы өзі қосқан сан тек қорға дейін азаяды
+		wanted = max(current.Quantity, item.Quantity)
+	}
+	quantity := min(wanted, item.Variant.Stock)
+	switch {
+	case quantity < wanted:
+		adjustment.Reason = MergeLowStock
+	case current != nil:
+		adjustment.Reason = MergeAlreadyInCart
+	default:
+		return quantity, nil
+	}
+	adjustment.Quantity = quantity
+	return quantity, adjustment
+}
+
+// ExpireGuestCarts ttl бойы қолданылмаған қонақ себеттерін жояды
+func ExpireGuestCarts(db *gorm.DB, ttl time.Duration) (int64, error) {
+	result := db.Where("updated_at <= ?", time.Now().Add(-ttl)).Delete(&GuestCart{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeLine(t *testing.T) {
+	guestItem := func(quantity, stock uint) GuestCartItem {
+		return GuestCartItem{
+			ProductID: 1,
+			VariantID: 10,
+			Quantity:  quantity,
+			Variant:   ProductVariant{ID: 10, ProductID: 1, Stock: stock},
+		}
+	}
+	adjustment := func(requested, quantity uint, reason string) *MergeAdjustment {
+		return &MergeAdjustment{ProductID: 1, VariantID: 10, Requested: requested, Quantity: quantity, Reason: reason}
+	}
+
+	tests := []struct {
+		name       string
+		item       GuestCartItem
+		current    *CartItem
+		quantity   uint
+		adjustment *MergeAdjustment
+	}{
+		{name: "copied as is", item: guestItem(2, 5), quantity: 2},
+		{name: "capped by stock", item: guestItem(4, 3), quantity: 3, adjustment: adjustment(4, 3, MergeLowStock)},
+		{name: "out of stock", item: guestItem(2, 0), quantity: 0, adjustment: adjustment(2, 0, MergeLowStock)},
+		{
+			name: "variant deleted", quantity: 0, adjustment: adjustment(2, 0, MergeUnavailable),
+			item: GuestCartItem{ProductID: 1, VariantID: 10, Quantity: 2},
+		},
+		{
+			name: "variant moved to another product", quantity: 0, adjustment: adjustment(2, 0, MergeUnavailable),
+			item: GuestCartItem{ProductID: 1, VariantID: 10, Quantity: 2, Variant: ProductVariant{ID: 10, ProductID: 2, Stock: 5}},
+		},
+		{
+			name: "unavailable variant leaves user line alone", current: &CartItem{Quantity: 3}, quantity: 3, adjustment: adjustment(2, 3, MergeUnavailable),
+			item: GuestCartItem{ProductID: 1, VariantID: 10, Quantity: 2},
+		},
+		{name: "guest quantity is larger", item: guestItem(4, 5), current: &CartItem{Quantity: 1}, quantity: 4, adjustment: adjustment(4, 4, MergeAlreadyInCart)},
+		{name: "user quantity is kept", item: guestItem(1, 5), current: &CartItem{Quantity: 3}, quantity: 3, adjustment: adjustment(1, 3, MergeAlreadyInCart)},
+		{name: "user quantity capped by stock", item: guestItem(1, 2), current: &CartItem{Quantity: 3}, quantity: 2, adjustment: adjustment(1, 2, MergeLowStock)},
+		{name: "user line emptied without stock", item: guestItem(2, 0), current: &CartItem{Quantity: 1}, quantity: 0, adjustment: adjustment(2, 0, MergeLowStock)},
+		{name: "larger quantity capped by stock", item: guestItem(6, 4), current: &CartItem{Quantity: 2}, quantity: 4, adjustment: adjustment(6, 4, MergeLowStock)},
+		{name: "merged quantity fits stock exactly", item: guestItem(4, 4), current: &CartItem{Quantity: 2}, quantity: 4, adjustment: adjustment(4, 4, MergeAlreadyInCart)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quantity, adjustment := mergeLine(tt.item, tt.current)
+			if quantity != tt.quantity {
+				t.Errorf("quantity = %d, want %d", quantity, tt.quantity)
+			}
+			if !reflect.DeepEqual(adjustment, tt.adjustment) {
+				t.Errorf("adjustment = %+v, want %+v", adjustment, tt.adjustment)
+			}
+		})
+	}
+}
+
+func TestAddGuestCartItemDuplicate(t *testing.T) {
+	db := testDB(t)
+	product := testProduct(t, db, 5)
+	cart, err := CreateGuestCart(db, "test-token-hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AddGuestCartItem(db, cart, &GuestCartItem{ProductID: product.ID, Quantity: 1}); err != nil {
+		t.Fatalf("first AddGuestCartItem() error = %v", err)
+	}
+	// Екінші жол бірегей индекске тіреледі де, ON CONFLICT DO NOTHING ештеңе қоспайды
+	if _, err := AddGuestCartItem(db, cart, &GuestCartItem{ProductID: product.ID, Quantity: 2}); !errors.Is(err, ErrAlreadyInCart) {
+		t.Fatalf("second AddGuestCartItem() error = %v, want ErrAlreadyInCart", err)
+	}
+
+	var items []GuestCartItem
+	if err := db.Where("guest_cart_id = ?", cart.ID).Find(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Quantity != 1 {
+		t.Errorf("cart items = %+v, want one line with quantity 1", items)
+	}
+}
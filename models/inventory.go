@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ReservationHeld      = "held"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+)
+
+var ErrReservationExpired = errors.New("stock reservation has expired")
+
+// StockReservation тапсырыс үшін ұсталған қор. Резерв жасалғанда Product.Stock бірден азаяды,
+// сондықтан қатар рәсімделген тапсырыстар бір бірлікті екі рет ала алмайды.
+type StockReservation struct {
+	ID          uint      `gorm:"primaryKey"`
+	OrderID     uint      `gorm:"not null;index"`
+	ProductID   uint      `gorm:"not null"`
+	VariantID   uint      `gorm:"not null"`
+	WarehouseID uint      `gorm:"not null"`
+	Quantity    uint      `gorm:"not null"`
+	Status      string    `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// lockStockRows қоры өзгеретін өнімдер мен нұсқаларды құлыптап, ID бойынша қайтарады.
+// Қорды өзгертетін барлық жолдар бір ретпен құлыптайды: алдымен өнімдер, содан кейін
+// нұсқалар, әрқайсысы ID өсуі бойынша, ал қойма қалдықтары олардан кейін. Әйтпесе қатар
+// тапсырыстар мен қор өзгерістері бір-бірін күтіп, deadlock-қа түседі.
+func lockStockRows(tx *gorm.DB, productIDs, variantIDs []uint) (map[uint]Product, map[uint]ProductVariant, error) {
+	productsByID := make(map[uint]Product, len(productIDs))
+	if len(productIDs) > 0 {
+		var products []Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", productIDs).Order("id").Find(&products).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, product := range products {
+			productsByID[product.ID] = product
+		}
+	}
+
+	variantsByID := make(map[uint]ProductVariant, len(variantIDs))
+	if len(variantIDs) > 0 {
+		var variants []ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", variantIDs).Order("id").Find(&variants).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, variant := range variants {
+			variantsByID[variant.ID] = variant
+		}
+	}
+	return productsByID, variantsByID, nil
+}
+
+// reserveStock қоймаларға бөлінген тапсырыс жолдары үшін қорды азайтып, резерв жазбаларын жасайды.
+// Транзакция ішінде шақырылуы керек.
+func reserveStock(tx *gorm.DB, settings Settings, orderID uint, items []OrderItem) error {
+	type reservationKey struct{ productID, variantID, warehouseID uint }
+	quantities := map[reservationKey]uint{}
+	var keys []reservationKey
+	for _, item := range items {
+		key := reservationKey{productID: item.ProductID, variantID: item.VariantID}
+		if item.WarehouseID != nil {
+			key.warehouseID = *item.WarehouseID
+		}
+		if _, seen := quantities[key]; !seen {
+			keys = append(keys, key)
+		}
+		quantities[key] += item.Quantity
+	}
+	// Deadlock болмас үшін қалдықтар әрқашан бір ретпен жаңартылады
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].variantID != keys[j].variantID {
+			return keys[i].variantID < keys[j].variantID
+		}
+		return keys[i].warehouseID < keys[j].warehouseID
+	})
+
+	expiresAt := time.Now().Add(settings.ReservationTTL)
+	for _, key := range keys {
+		quantity := quantities[key]
+
+		sale := StockMovement{
+			ProductID:   key.productID,
+			VariantID:   key.variantID,
+			WarehouseID: key.warehouseID,
+			Delta:       -int64(quantity),
+			Reason:      MovementSale,
+			OrderID:     &orderID,
+		}
+		if err := ApplyStockMovement(tx, &sale); err != nil {
+			return err
+		}
+
+		reservation := StockReservation{
+			OrderID:     orderID,
+			ProductID:   key.productID,
+			VariantID:   sale.VariantID,
+			WarehouseID: sale.WarehouseID,
+			Quantity:    quantity,
+			Status:      ReservationHeld,
+			ExpiresAt:   expiresAt,
+		}
+		if err := tx.Create(&reservation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitReservations төлем кезінде ұсталған қорды біржола бекітеді
+func commitReservations(tx *gorm.DB, orderID uint) error {
+	var reservations []StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status = ?", orderID, ReservationHeld).Find(&reservations).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reservation := range reservations {
+		if now.After(reservation.ExpiresAt) {
+			return ErrReservationExpired
+		}
+	}
+
+	return tx.Model(&StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, ReservationHeld).
+		Update("status", ReservationCommitted).Error
+}
+
+// releaseReservations тапсырыс бойынша әлі қайтарылмаған қорды қоймаға қайтарады.
+// Pending тапсырыстың резерві held, ал төленгеннің резерві committed күйде болады;
+// екеуі де әлі қоймадан шықпаған, сондықтан тек жөнелтілмеген тапсырыстар үшін шақырылуы керек.
+func releaseReservations(tx *gorm.DB, orderID uint) error {
+	var order Order
+	if err := tx.Select("status").First(&order, orderID).Error; err != nil {
+		return err
+	}
+	statuses := []string{ReservationHeld}
+	if order.Status == OrderStatusPaid || order.Status == OrderStatusProcessing {
+		statuses = append(statuses, ReservationCommitted)
+	}
+
+	var reservations []StockReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND status IN ?", orderID, statuses).
+		Order("variant_id, warehouse_id").Find(&reservations).Error; err != nil {
+		return err
+	}
+	var productIDs, variantIDs []uint
+	for _, reservation := range reservations {
+		productIDs = append(productIDs, reservation.ProductID)
+		variantIDs = append(variantIDs, reservation.VariantID)
+	}
+	if _, _, err := lockStockRows(tx, productIDs, variantIDs); err != nil {
+		return err
+	}
+
+	for _, reservation := range reservations {
+		restock := StockMovement{
+			ProductID:   reservation.ProductID,
+			VariantID:   reservation.VariantID,
+			WarehouseID: reservation.WarehouseID,
+			Delta:       int64(reservation.Quantity),
+			Reason:      MovementReturn,
+			OrderID:     &orderID,
+			Note:        "reservation released",
+		}
+		if err := ApplyStockMovement(tx, &restock); err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", ReservationReleased).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations мерзімі өткен резервтері бар pending тапсырыстарды болдырмайды
+// және қорды қайтарады. Болдырылмаған тапсырыстар санын қайтарады.
+func ReleaseExpiredReservations(db *gorm.DB) (int, error) {
+	var orderIDs []uint
+	err := db.Model(&StockReservation{}).
+		Distinct("order_id").
+		Where("status = ? AND expires_at < ?", ReservationHeld, time.Now()).
+		Pluck("order_id", &orderIDs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	cancelled := 0
+	for _, orderID := range orderIDs {
+		expired := false
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var order Order
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+				return err
+			}
+			// Тапсырыс осы арада төленген болуы мүмкін
+			if order.Status != OrderStatusPending {
+				return nil
+			}
+
+			if err := releaseReservations(tx, order.ID); err != nil {
+				return err
+			}
+			if err := recordOrderStatus(tx, order.ID, order.Status, OrderStatusCancelled, 0, "stock reservation expired"); err != nil {
+				return err
+			}
+			expired = true
+			return tx.Model(&order).Update("status", OrderStatusCancelled).Error
+		})
+		if err != nil {
+			return cancelled, err
+		}
+		if expired {
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
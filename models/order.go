@@ -1,24 +1,83 @@
 package models
 
 import (
+	"NomadShop/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
+const OrderStatusPending = "pending"
+
 type Order struct {
-	ID         uint        `gorm:"primaryKey"`
-	UserID     uint        `gorm:"not null"`
-	OrderDate  time.Time   `gorm:"not null"`
-	Status     string      `gorm:"not null"` // "pending", "completed", "shipped", т.б.
-	Total      float64     `gorm:"not null"`
-	User       User        `gorm:"foreignKey:UserID;references:ID"`
-	OrderItems []OrderItem `gorm:"foreignKey:OrderID;references:ID"`
+	ID           uint        `gorm:"primaryKey"`
+	UserID       uint        `gorm:"not null"`
+	OrderDate    time.Time   `gorm:"not null"`
+	Status       string      `gorm:"not null"`                          // OrderStatus* тұрақтыларының бірі, тек TransitionOrder арқылы өзгереді
+	Subtotal     money.Money `gorm:"embedded;embeddedPrefix:subtotal_"` // тауарлардың жеңілдіксіз сомасы
+	Discount     money.Money `gorm:"embedded;embeddedPrefix:discount_"` // жеткізу жеңілдігімен бірге
+	Shipping     money.Money `gorm:"embedded;embeddedPrefix:shipping_"`
+	Tax          money.Money `gorm:"embedded;embeddedPrefix:tax_"`
+	TaxIncluded  bool        `gorm:"not null"`                              // салық бағаға кірген және Total-ға қосылмаған
+	Total        money.Money `gorm:"embedded;embeddedPrefix:total_"`        // Subtotal - Discount + Shipping (+ Tax)
+	ExchangeRate string      `gorm:"type:numeric(20,8);not null;default:1"` // төлем кезіндегі бағам, негізгі валюта үшін 1
+	User         User        `gorm:"foreignKey:UserID;references:ID"`
+	OrderItems   []OrderItem `gorm:"foreignKey:OrderID;references:ID"`
+
+	StatusHistory     []OrderStatusHistory `gorm:"foreignKey:OrderID;references:ID" json:",omitempty"`
+	CouponRedemptions []CouponRedemption   `gorm:"foreignKey:OrderID;references:ID" json:",omitempty"`
+}
+
+// CreateOrder берілген жолдардан себетсіз тапсырыс жасайды. Клиент жіберген бағалар мен
+// сомалар еленбейді: жолдар Checkout сияқты сервердегі бағалармен, акциялармен және
+// купонмен бағаланады. Нұсқасы көрсетілмеген жолдар әдепкі нұсқаға жатады.
+func CreateOrder(db *gorm.DB, settings Settings, userID uint, items []OrderItem, options CheckoutOptions) (*Order, error) {
+	if len(items) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	var order *Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		lines := make([]orderLine, 0, len(items))
+		for _, item := range items {
+			variant, err := ResolveVariant(tx, item.ProductID, item.VariantID)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, orderLine{ProductID: item.ProductID, VariantID: variant.ID, Quantity: item.Quantity})
+		}
+
+		var err error
+		order, err = placeOrder(tx, settings, userID, lines, options, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loadPlacedOrder(db, order.ID)
 }
 
-// Миграция функциясы
-func CreateOrder(db *gorm.DB, order *Order) (*Order, error) {
-	err := db.Create(&order).Error
-	return order, err
+// DeleteOrder тапсырысты жояды. Әлі жөнелтілмеген (pending, paid, processing) тапсырыстың
+// қоры қоймаға қайтарылады; жөнелтілген тапсырыстың тауары қоймада жоқ, ал болдырылмаған
+// тапсырыстың қоры бұрын қайтарылған, сондықтан олар үшін қор өзгермейді.
+func DeleteOrder(db *gorm.DB, orderID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var order Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+		switch order.Status {
+		case OrderStatusPending, OrderStatusPaid, OrderStatusProcessing:
+			if err := releaseReservations(tx, order.ID); err != nil {
+				return err
+			}
+		}
+		// Күй тарихы мен резервтер ON DELETE CASCADE арқылы жойылады
+		if err := tx.Where("order_id = ?", order.ID).Delete(&OrderItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&order).Error
+	})
 }
 
 func GetOrdersByUser(db *gorm.DB, userID uint) ([]Order, error) {
@@ -1,14 +1,36 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"NomadShop/money"
+	"gorm.io/gorm"
+)
+
+const (
+	DiscountPromotion = "promotion"
+	DiscountCoupon    = "coupon"
+)
+
+// LineDiscount жолға бөлінген бір жеңілдік. Акция кейін өзгерсе немесе жойылса да,
+// тапсырыста оның атауы мен сомасы сақталып қалады.
+type LineDiscount struct {
+	Source   string      `json:"source"` // DiscountPromotion немесе DiscountCoupon
+	SourceID uint        `json:"source_id"`
+	Name     string      `json:"name"`
+	Amount   money.Money `json:"amount"`
+}
 
 type OrderItem struct {
-	ID        uint    `gorm:"primaryKey"`
-	OrderID   uint    `gorm:"not null"`
-	ProductID uint    `gorm:"not null"`
-	Quantity  uint    `gorm:"not null"`
-	Price     float64 `gorm:"not null"`
-	Product   Product `gorm:"foreignKey:ProductID;references:ID"`
+	ID          uint           `gorm:"primaryKey"`
+	OrderID     uint           `gorm:"not null"`
+	ProductID   uint           `gorm:"not null"`
+	VariantID   uint           `gorm:"not null"`
+	Quantity    uint           `gorm:"not null"`
+	Price       money.Money    `gorm:"embedded;embeddedPrefix:price_"`
+	Discount    money.Money    `gorm:"embedded;embeddedPrefix:discount_"` // бүкіл жолдың жеңілдігі (Price × Quantity-ден)
+	Discounts   []LineDiscount `gorm:"type:jsonb;serializer:json;not null"`
+	WarehouseID *uint          // жол қай қоймадан жөнелтіледі
+	Product     Product        `gorm:"foreignKey:ProductID;references:ID"`
+	Variant     ProductVariant `gorm:"foreignKey:VariantID;references:ID"`
 }
 
 func CreateOrderItem(db *gorm.DB, orderItem *OrderItem) (*OrderItem, error) {
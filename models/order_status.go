@@ -0,0 +1,129 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	OrderStatusPaid       = "paid"
+	OrderStatusProcessing = "processing"
+	OrderStatusShipped    = "shipped"
+	OrderStatusDelivered  = "delivered"
+	OrderStatusCancelled  = "cancelled"
+	OrderStatusRefunded   = "refunded"
+)
+
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// Тапсырыстың өмірлік циклі: pending → paid → processing → shipped → delivered,
+// және cancelled/refunded аяқталу күйлері
+var orderTransitions = map[string][]string{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:    {OrderStatusDelivered},
+	OrderStatusDelivered:  {OrderStatusRefunded},
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %q to %q", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// OrderStatusHistory тапсырыс күйінің әр өзгерісін кім және қашан жасағанын сақтайды
+type OrderStatusHistory struct {
+	ID          uint   `gorm:"primaryKey"`
+	OrderID     uint   `gorm:"not null;index"`
+	FromStatus  string `gorm:"not null"`
+	ToStatus    string `gorm:"not null"`
+	ChangedByID *uint
+	Note        string
+	CreatedAt   time.Time
+}
+
+func IsOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func CanTransitionOrder(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionOrder тапсырыс күйін ауыстырып, тарихқа жазады
+func TransitionOrder(db *gorm.DB, orderID uint, to string, changedByID uint, note string) (*Order, error) {
+	var order Order
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+		if !CanTransitionOrder(order.Status, to) {
+			return &TransitionError{From: order.Status, To: to}
+		}
+
+		switch to {
+		case OrderStatusPaid:
+			if err := commitReservations(tx, order.ID); err != nil {
+				return err
+			}
+		case OrderStatusCancelled:
+			if err := releaseReservations(tx, order.ID); err != nil {
+				return err
+			}
+		case OrderStatusRefunded:
+			// Жөнелтілмеген тапсырыстың қоры қайтарылады; жеткізілген тауар клиентте қалады
+			if order.Status == OrderStatusPaid || order.Status == OrderStatusProcessing {
+				if err := releaseReservations(tx, order.ID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := recordOrderStatus(tx, order.ID, order.Status, to, changedByID, note); err != nil {
+			return err
+		}
+		order.Status = to
+		return tx.Model(&order).Update("status", to).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func GetOrderStatusHistory(db *gorm.DB, orderID uint) ([]OrderStatusHistory, error) {
+	var history []OrderStatusHistory
+	err := db.Where("order_id = ?", orderID).Order("created_at, id").Find(&history).Error
+	return history, err
+}
+
+func recordOrderStatus(db *gorm.DB, orderID uint, from, to string, changedByID uint, note string) error {
+	entry := OrderStatusHistory{OrderID: orderID, FromStatus: from, ToStatus: to, Note: note}
+	if changedByID != 0 {
+		entry.ChangedByID = &changedByID
+	}
+	return db.Create(&entry).Error
+}
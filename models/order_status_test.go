@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTransitionOrderRefundRestoresStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      []string // refunded алдындағы күйлер
+		wantStock uint
+	}{
+		{"refund paid order", []string{OrderStatusPaid}, 5},
+		{"refund processing order", []string{OrderStatusPaid, OrderStatusProcessing}, 5},
+		{"refund delivered order", []string{OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped, OrderStatusDelivered}, 3},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testDB(t)
+			product := testProduct(t, db, 5)
+			user, err := CreateUser(db, &User{
+				Username: fmt.Sprintf("refund-%d", i),
+				Email:    fmt.Sprintf("refund-%d@example.com", i),
+				Password: "secret",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			order, err := CreateOrder(db, DefaultSettings, user.ID, []OrderItem{{ProductID: product.ID, Quantity: 2}}, CheckoutOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, status := range append(tt.path, OrderStatusRefunded) {
+				if _, err := TransitionOrder(db, order.ID, status, 0, ""); err != nil {
+					t.Fatalf("TransitionOrder(%s) error = %v", status, err)
+				}
+			}
+
+			var updated Product
+			if err := db.First(&updated, product.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			var variant ProductVariant
+			if err := db.Where("product_id = ?", product.ID).First(&variant).Error; err != nil {
+				t.Fatal(err)
+			}
+			if updated.Stock != tt.wantStock || variant.Stock != tt.wantStock {
+				t.Errorf("product stock = %d, variant stock = %d, want %d", updated.Stock, variant.Stock, tt.wantStock)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	PermProductWrite    = "product:write"
+	PermInventoryWrite  = "inventory:write"
+	PermCategoryWrite   = "category:write"
+	PermOrderRead       = "order:read"
+	PermOrderWrite      = "order:write"
+	PermOrderRefund     = "order:refund"
+	PermUserRead        = "user:read"
+	PermUserManage      = "user:manage"
+	PermRoleManage      = "role:manage"
+	PermCurrencyManage  = "currency:manage"
+	PermCouponManage    = "coupon:manage"
+	PermPromotionManage = "promotion:manage"
+)
+
+type Permission struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null;unique"`
+	Description string
+}
+
+// Әдепкі рөлдер мен олардың рұқсаттары
+var defaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermProductWrite, PermInventoryWrite, PermCategoryWrite, PermOrderRead, PermOrderWrite, PermOrderRefund,
+		PermUserRead, PermUserManage, PermRoleManage, PermCurrencyManage, PermCouponManage, PermPromotionManage,
+	},
+	RoleManager: {
+		PermProductWrite, PermInventoryWrite, PermCategoryWrite, PermOrderRead, PermOrderWrite,
+		PermCurrencyManage, PermCouponManage, PermPromotionManage,
+	},
+	RoleCustomer: {},
+}
+
+func GetPermissions(db *gorm.DB) ([]Permission, error) {
+	var permissions []Permission
+	err := db.Find(&permissions).Error
+	return permissions, err
+}
+
+func GetPermissionByID(db *gorm.DB, id uint) (*Permission, error) {
+	var permission Permission
+	err := db.First(&permission, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+func CreatePermission(db *gorm.DB, permission *Permission) (*Permission, error) {
+	err := db.Create(&permission).Error
+	return permission, err
+}
+
+func UpdatePermission(db *gorm.DB, id uint, permission *Permission) (*Permission, error) {
+	err := db.Model(&Permission{}).Where("id = ?", id).Updates(permission).Error
+	return permission, err
+}
+
+func DeletePermission(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Алдымен рөлдермен байланысын жою
+		if err := tx.Exec("DELETE FROM role_permissions WHERE permission_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Permission{}, id).Error
+	})
+}
+
+func AddRolePermission(db *gorm.DB, roleID, permissionID uint) error {
+	return db.Model(&Role{ID: roleID}).Association("Permissions").Append(&Permission{ID: permissionID})
+}
+
+func RemoveRolePermission(db *gorm.DB, roleID, permissionID uint) error {
+	return db.Model(&Role{ID: roleID}).Association("Permissions").Delete(&Permission{ID: permissionID})
+}
+
+// GetUserPermissions пайдаланушының барлық рөлдері арқылы берілген рұқсаттарды қайтарады
+func GetUserPermissions(db *gorm.DB, userID uint) ([]string, error) {
+	var names []string
+	err := db.Model(&Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
+
+// SeedDefaultRoles әдепкі рөлдер мен рұқсаттарды жасайды, бар жазбаларды өзгертпейді
+func SeedDefaultRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range defaultRolePermissions {
+			var role Role
+			if err := tx.Where(Role{Name: roleName}).FirstOrCreate(&role).Error; err != nil {
+				return err
+			}
+
+			for _, name := range permissionNames {
+				var permission Permission
+				if err := tx.Where(Permission{Name: name}).FirstOrCreate(&permission).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("INSERT INTO role_permissions (role_id, permission_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+					role.ID, permission.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,338 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+)
+
+// PricedLine бағасы есептелген себет жолы. Discount — осы жолға бөлінген барлық жеңілдік,
+// ал Discounts оның акциялар мен купон бойынша бөлінісі.
+type PricedLine struct {
+	CartItemID uint           `json:"cart_item_id,omitempty"`
+	ProductID  uint           `json:"product_id"`
+	VariantID  uint           `json:"variant_id"`
+	CategoryID uint           `json:"category_id"`
+	Quantity   uint           `json:"quantity"`
+	UnitPrice  money.Money    `json:"unit_price"`
+	Total      money.Money    `json:"total"`
+	Discount   money.Money    `json:"discount"`
+	Discounts  []LineDiscount `json:"discounts,omitempty"`
+}
+
+func (l *PricedLine) remaining() int64 {
+	return l.Total.Amount - l.Discount.Amount
+}
+
+// AppliedPromotion бағаға автоматты түрде қолданылған акция
+type AppliedPromotion struct {
+	ID       uint        `json:"id"`
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Discount money.Money `json:"discount"`
+}
+
+// AppliedCoupon бағаға қолданылған купон
+type AppliedCoupon struct {
+	ID       uint        `json:"-"`
+	Code     string      `json:"code"`
+	Type     string      `json:"type"`
+	Discount money.Money `json:"discount"` // жеткізу жеңілдігімен бірге
+}
+
+// PriceBreakdown себеттің тапсырыс валютасындағы толық есебі:
+// Total = Subtotal - Discount + Shipping - ShippingDiscount (+ Tax, егер салық бағаға кірмесе).
+// Алдымен акциялар, содан кейін қалған сомаға купон қолданылады. Салық жеңілдіктен кейінгі
+// тауарлар сомасынан есептеледі, жеткізуге салынбайды.
+type PriceBreakdown struct {
+	Currency         string             `json:"currency"`
+	Lines            []PricedLine       `json:"lines"`
+	Subtotal         money.Money        `json:"subtotal"`
+	Discount         money.Money        `json:"discount"`
+	Shipping         money.Money        `json:"shipping"`
+	ShippingDiscount money.Money        `json:"shipping_discount"`
+	Tax              money.Money        `json:"tax"`
+	TaxIncluded      bool               `json:"tax_included"`
+	Total            money.Money        `json:"total"`
+	Promotions       []AppliedPromotion `json:"promotions,omitempty"`
+	Coupon           *AppliedCoupon     `json:"coupon,omitempty"`
+
+	taxRate int64 // Settings.TaxRate: жиынтық әр жеңілдіктен кейін қайта есептеледі
+}
+
+// QuoteCart пайдаланушының себетін currency валютасында бағалайды: акцияларды қолданады
+// және code берілсе, купонды қолданып көреді. Ештеңе сақталмайды: нақты жеңілдік Checkout кезінде қайта есептеледі.
+func QuoteCart(db *gorm.DB, settings Settings, userID uint, currency, code string) (*PriceBreakdown, error) {
+	if currency == "" {
+		currency = settings.Currency
+	}
+	converter, err := LoadConverter(db, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	cartItems, err := loadCart(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartItems) == 0 {
+		return nil, ErrCartEmpty
+	}
+	lines, warnings, err := cartLines(converter, currency, cartItems)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		if warning.Code == CartWarningUnavailable {
+			return nil, fmt.Errorf("%w: product %d", ErrProductUnavailable, warning.ProductID)
+		}
+	}
+	return priceCart(db, settings, converter, currency, lines, userID, code)
+}
+
+// priceLine нұсқаның бір бірлік бағасын тапсырыс валютасына түрлендіріп, жол жасайды
+func priceLine(converter *money.Converter, currency string, product Product, variant ProductVariant, quantity uint) (PricedLine, error) {
+	price, err := converter.Convert(variant.UnitPrice(product.Price), currency)
+	if err != nil {
+		return PricedLine{}, err
+	}
+	return PricedLine{
+		ProductID:  product.ID,
+		VariantID:  variant.ID,
+		CategoryID: product.CategoryID,
+		Quantity:   quantity,
+		UnitPrice:  price,
+		Total:      price.Mul(int64(quantity)),
+		Discount:   money.Zero(currency),
+	}, nil
+}
+
+func newPriceBreakdown(converter *money.Converter, settings Settings, currency string, lines []PricedLine) (*PriceBreakdown, error) {
+	breakdown := &PriceBreakdown{
+		Currency:         currency,
+		Lines:            lines,
+		Shipping:         money.Zero(currency),
+		ShippingDiscount: money.Zero(currency),
+		TaxIncluded:      settings.TaxIncluded,
+		taxRate:          settings.TaxRate,
+	}
+	// Бос себетті жеткізу ақысыз
+	if !settings.ShippingRate.IsZero() && len(lines) > 0 {
+		shipping, err := converter.Convert(settings.ShippingRate, currency)
+		if err != nil {
+			return nil, err
+		}
+		breakdown.Shipping = shipping
+	}
+	breakdown.total()
+	return breakdown, nil
+}
+
+// total жолдардан жиынтықтарды қайта есептейді
+func (b *PriceBreakdown) total() {
+	b.Subtotal = money.Zero(b.Currency)
+	b.Discount = money.Zero(b.Currency)
+	for _, line := range b.Lines {
+		b.Subtotal = b.Subtotal.Add(line.Total)
+		b.Discount = b.Discount.Add(line.Discount)
+	}
+	b.Tax = money.New(taxOf(b.Subtotal.Amount-b.Discount.Amount, b.taxRate, b.TaxIncluded), b.Currency)
+	b.Total = b.Subtotal.Sub(b.Discount).Add(b.Shipping).Sub(b.ShippingDiscount)
+	if !b.TaxIncluded {
+		b.Total = b.Total.Add(b.Tax)
+	}
+}
+
+// taxOf amount сомасының салығы, жарты тиын жоғары дөңгелектенеді. included болса,
+// салық amount ішінен бөлініп алынады: amount × rate / (100% + rate).
+func taxOf(amount, rate int64, included bool) int64 {
+	if rate <= 0 || amount <= 0 {
+		return 0
+	}
+	base := int64(10000)
+	if included {
+		base += rate
+	}
+	return (amount*rate + base/2) / base
+}
+
+// applyCoupon купонның шарттарын тексеріп, жеңілдікті купон қолданылатын жолдарға
+// олардың сомасына пропорционал бөледі
+func (b *PriceBreakdown) applyCoupon(db *gorm.DB, converter *money.Converter, coupon *Coupon, userID uint, now time.Time) error {
+	if err := checkCouponUsable(db, coupon, userID, now); err != nil {
+		return err
+	}
+
+	if coupon.MinOrder != nil {
+		minOrder, err := converter.Convert(*coupon.MinOrder, b.Currency)
+		if err != nil {
+			return err
+		}
+		if b.Subtotal.Cmp(minOrder) < 0 {
+			return &CouponError{Code: coupon.Code, Reason: "order subtotal must be at least " + minOrder.String()}
+		}
+	}
+
+	lines, err := b.eligibleLines(db, coupon.ProductIDs, coupon.CategoryIDs)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return &CouponError{Code: coupon.Code, Reason: "no products in the cart are eligible"}
+	}
+
+	applied := &AppliedCoupon{ID: coupon.ID, Code: coupon.Code, Type: coupon.Type, Discount: money.Zero(b.Currency)}
+	source := LineDiscount{Source: DiscountCoupon, SourceID: coupon.ID, Name: coupon.Code}
+	switch coupon.Type {
+	case CouponPercentage:
+		discount := percentOf(b.remainingOf(lines), coupon.Percent)
+		applied.Discount = money.New(b.allocate(lines, b.remainingWeights(lines), discount, source), b.Currency)
+	case CouponFixed:
+		amount, err := converter.Convert(*coupon.Amount, b.Currency)
+		if err != nil {
+			return err
+		}
+		applied.Discount = money.New(b.allocate(lines, b.remainingWeights(lines), amount.Amount, source), b.Currency)
+	case CouponFreeShipping:
+		if b.Shipping.IsZero() {
+			return &CouponError{Code: coupon.Code, Reason: "shipping is already free"}
+		}
+		b.ShippingDiscount = b.Shipping
+		applied.Discount = b.Shipping
+	}
+
+	b.Coupon = applied
+	b.total()
+	return nil
+}
+
+// eligibleLines акция немесе купон қолданылатын, әлі толық жеңілдік алмаған жолдардың индекстері
+func (b *PriceBreakdown) eligibleLines(db *gorm.DB, scopeProducts, scopeCategories []uint) ([]int, error) {
+	productIDs := make([]uint, 0, len(b.Lines))
+	for _, line := range b.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	eligible, err := eligibleProducts(db, scopeProducts, scopeCategories, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	var lines []int
+	for i := range b.Lines {
+		if eligible[b.Lines[i].ProductID] && b.Lines[i].remaining() > 0 {
+			lines = append(lines, i)
+		}
+	}
+	return lines, nil
+}
+
+// eligibleProducts productIDs ішінен жеңілдік қолданылатын өнімдерді табады. Шектеу берілмесе,
+// барлығы; әйтпесе scopeProducts өнімдері және scopeCategories категорияларындағы (ішкілерімен бірге) өнімдер.
+func eligibleProducts(db *gorm.DB, scopeProducts, scopeCategories []uint, productIDs []uint) (map[uint]bool, error) {
+	eligible := make(map[uint]bool, len(productIDs))
+	if len(scopeProducts) == 0 && len(scopeCategories) == 0 {
+		for _, id := range productIDs {
+			eligible[id] = true
+		}
+		return eligible, nil
+	}
+
+	scope := db.Session(&gorm.Session{NewDB: true}).Where("id IN ?", scopeProducts)
+	for _, categoryID := range scopeCategories {
+		scope = scope.Or("category_id IN (?)", CategorySubtree(db, categoryID))
+	}
+	var ids []uint
+	err := db.Model(&Product{}).Where("id IN ?", productIDs).Where(scope).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		eligible[id] = true
+	}
+	return eligible, nil
+}
+
+func (b *PriceBreakdown) remainingWeights(lines []int) []int64 {
+	weights := make([]int64, len(lines))
+	for k, i := range lines {
+		weights[k] = b.Lines[i].remaining()
+	}
+	return weights
+}
+
+func (b *PriceBreakdown) remainingOf(lines []int) int64 {
+	var total int64
+	for _, i := range lines {
+		total += b.Lines[i].remaining()
+	}
+	return total
+}
+
+// percentOf amount-тың percent пайызы, жарты тиын жоғары дөңгелектенеді
+func percentOf(amount int64, percent int) int64 {
+	return (amount*int64(percent) + 50) / 100
+}
+
+// allocate amount жеңілдігін lines жолдарына weights салмағына пропорционал бөледі және
+// әр жолға source жазбасын қосады. Бөлгенде қалған тиындар ең үлкен қалдығы бар жолдарға
+// беріледі, сондықтан бөліктердің қосындысы дәл шығады. Жолдың жеңілдігі оның қалған
+// сомасынан аспайды; бөлінген сома қайтарылады.
+func (b *PriceBreakdown) allocate(lines []int, weights []int64, amount int64, source LineDiscount) int64 {
+	capacity := make([]int64, len(lines))
+	var totalWeight, totalCapacity int64
+	for k, i := range lines {
+		capacity[k] = b.Lines[i].remaining()
+		totalCapacity += capacity[k]
+		totalWeight += weights[k]
+	}
+	amount = min(amount, totalCapacity)
+	if amount <= 0 || totalWeight <= 0 {
+		return 0
+	}
+
+	shares := make([]int64, len(lines))
+	fractions := make([]*big.Int, len(lines))
+	var allocated int64
+	for k := range lines {
+		// amount * weight / totalWeight int64-тен асып кетпеуі үшін big.Int арқылы
+		product := new(big.Int).Mul(big.NewInt(amount), big.NewInt(weights[k]))
+		quo, rem := new(big.Int).QuoRem(product, big.NewInt(totalWeight), new(big.Int))
+		shares[k] = min(quo.Int64(), capacity[k])
+		fractions[k] = rem
+		allocated += shares[k]
+	}
+
+	order := make([]int, len(lines))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(x, y int) bool { return fractions[order[x]].Cmp(fractions[order[y]]) > 0 })
+	// Алдымен ең үлкен қалдықтарға бір-бір тиыннан, содан кейін бос орны бар жолдарға
+	for _, k := range order {
+		if allocated < amount && shares[k] < capacity[k] {
+			shares[k]++
+			allocated++
+		}
+	}
+	for _, k := range order {
+		extra := min(amount-allocated, capacity[k]-shares[k])
+		shares[k] += extra
+		allocated += extra
+	}
+
+	for k, i := range lines {
+		if shares[k] > 0 {
+			b.Lines[i].addDiscount(source, shares[k])
+		}
+	}
+	return amount
+}
+
+func (l *PricedLine) addDiscount(source LineDiscount, amount int64) {
+	source.Amount = money.New(amount, l.Total.Currency)
+	l.Discount = l.Discount.Add(source.Amount)
+	l.Discounts = append(l.Discounts, source)
+}
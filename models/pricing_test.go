@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"NomadShop/money"
+)
+
+// testBreakdown әр сома үшін бір данадан тұратын жолы бар есеп жасайды
+func testBreakdown(totals ...int64) *PriceBreakdown {
+	lines := make([]PricedLine, len(totals))
+	for i, total := range totals {
+		lines[i] = PricedLine{
+			ProductID: uint(i + 1),
+			VariantID: uint(i + 1),
+			Quantity:  1,
+			UnitPrice: money.New(total, "KZT"),
+			Total:     money.New(total, "KZT"),
+			Discount:  money.Zero("KZT"),
+		}
+	}
+	breakdown := &PriceBreakdown{
+		Currency:         "KZT",
+		Lines:            lines,
+		Shipping:         money.Zero("KZT"),
+		ShippingDiscount: money.Zero("KZT"),
+	}
+	breakdown.total()
+	return breakdown
+}
+
+func lineDiscounts(b *PriceBreakdown) []int64 {
+	discounts := make([]int64, len(b.Lines))
+	for i, line := range b.Lines {
+		discounts[i] = line.Discount.Amount
+	}
+	return discounts
+}
+
+func equalInts(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		amount  int64
+		percent int
+		want    int64
+	}{
+		{1000, 10, 100},
+		{1005, 10, 101}, // 100.5 жоғары дөңгелектенеді
+		{1004, 10, 100},
+		{999, 100, 999},
+		{0, 50, 0},
+	}
+	for _, tt := range tests {
+		if got := percentOf(tt.amount, tt.percent); got != tt.want {
+			t.Errorf("percentOf(%d, %d) = %d, want %d", tt.amount, tt.percent, got, tt.want)
+		}
+	}
+}
+
+func TestTaxOf(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		rate     int64
+		included bool
+		want     int64
+	}{
+		{10000, 1200, false, 1200},
+		{11200, 1200, true, 1200},
+		{1, 5000, false, 1},
+		{1, 4000, false, 0},
+		{0, 1200, false, 0},
+		{-500, 1200, false, 0},
+		{10000, 0, false, 0},
+	}
+	for _, tt := range tests {
+		if got := taxOf(tt.amount, tt.rate, tt.included); got != tt.want {
+			t.Errorf("taxOf(%d, %d, %v) = %d, want %d", tt.amount, tt.rate, tt.included, got, tt.want)
+		}
+	}
+}
+
+func TestNewPriceBreakdownSettings(t *testing.T) {
+	converter := &money.Converter{Base: "KZT", Rounding: money.Rounding{Mode: money.RoundHalfUp}}
+	shipping := money.New(150000, "KZT")
+
+	tests := []struct {
+		name     string
+		settings Settings
+		lines    int
+		shipping int64
+		tax      int64
+		total    int64
+	}{
+		{"defaults", DefaultSettings, 1, 0, 0, 10000},
+		{"flat shipping", Settings{ShippingRate: shipping}, 1, 150000, 0, 160000},
+		{"empty cart ships free", Settings{ShippingRate: shipping}, 0, 0, 0, 0},
+		{"tax added", Settings{TaxRate: 1200}, 1, 0, 1200, 11200},
+		{"tax included", Settings{TaxRate: 1200, TaxIncluded: true}, 1, 0, 1071, 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := testBreakdown(10000).Lines[:tt.lines]
+			b, err := newPriceBreakdown(converter, tt.settings, "KZT", lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Shipping.Amount != tt.shipping || b.Tax.Amount != tt.tax || b.Total.Amount != tt.total {
+				t.Errorf("shipping, tax, total = %d, %d, %d, want %d, %d, %d",
+					b.Shipping.Amount, b.Tax.Amount, b.Total.Amount, tt.shipping, tt.tax, tt.total)
+			}
+			if b.TaxIncluded != tt.settings.TaxIncluded {
+				t.Errorf("TaxIncluded = %v, want %v", b.TaxIncluded, tt.settings.TaxIncluded)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	const big = int64(1) << 50
+	tests := []struct {
+		name    string
+		totals  []int64
+		weights []int64 // nil болса, жолдардың қалған сомасы
+		amount  int64
+		want    []int64
+	}{
+		{name: "proportional", totals: []int64{1000, 2000, 3000}, amount: 600, want: []int64{100, 200, 300}},
+		{name: "remainder goes to first largest fraction", totals: []int64{100, 100, 100}, amount: 100, want: []int64{34, 33, 33}},
+		{name: "remainder by fraction size", totals: []int64{150, 250}, amount: 7, want: []int64{3, 4}},
+		{name: "share capped by line total", totals: []int64{100, 1000}, weights: []int64{1, 1}, amount: 600, want: []int64{100, 500}},
+		{name: "amount capped by cart", totals: []int64{100, 200}, amount: 1000, want: []int64{100, 200}},
+		{name: "zero amount", totals: []int64{100, 200}, amount: 0, want: []int64{0, 0}},
+		{name: "zero weights", totals: []int64{100, 200}, weights: []int64{0, 0}, amount: 50, want: []int64{0, 0}},
+		{name: "no int64 overflow", totals: []int64{big, big}, amount: big, want: []int64{big / 2, big / 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBreakdown(tt.totals...)
+			lines := make([]int, len(tt.totals))
+			for i := range lines {
+				lines[i] = i
+			}
+			weights := tt.weights
+			if weights == nil {
+				weights = b.remainingWeights(lines)
+			}
+			source := LineDiscount{Source: DiscountCoupon, SourceID: 7, Name: "SAVE"}
+
+			allocated := b.allocate(lines, weights, tt.amount, source)
+
+			var sum int64
+			for _, share := range tt.want {
+				sum += share
+			}
+			if allocated != sum {
+				t.Errorf("allocate() = %d, want %d", allocated, sum)
+			}
+			if got := lineDiscounts(b); !equalInts(got, tt.want) {
+				t.Errorf("line discounts = %v, want %v", got, tt.want)
+			}
+			for i, line := range b.Lines {
+				if tt.want[i] == 0 {
+					if len(line.Discounts) != 0 {
+						t.Errorf("line %d got discount entries %v", i, line.Discounts)
+					}
+					continue
+				}
+				if len(line.Discounts) != 1 || line.Discounts[0].SourceID != 7 || line.Discounts[0].Amount != money.New(tt.want[i], "KZT") {
+					t.Errorf("line %d discounts = %v", i, line.Discounts)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateSkipsDiscountedAmount(t *testing.T) {
+	b := testBreakdown(1000, 1000)
+	b.Lines[0].addDiscount(LineDiscount{Source: DiscountPromotion, SourceID: 1}, 800)
+	lines := []int{0, 1}
+
+	allocated := b.allocate(lines, b.remainingWeights(lines), 1500, LineDiscount{Source: DiscountCoupon})
+	if allocated != 1200 {
+		t.Errorf("allocate() = %d, want 1200", allocated)
+	}
+	if got := lineDiscounts(b); !equalInts(got, []int64{1000, 1000}) {
+		t.Errorf("line discounts = %v, want [1000 1000]", got)
+	}
+}
+
+func TestApplyCoupon(t *testing.T) {
+	active, inactive := true, false
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	amount := func(v int64) *money.Money {
+		m := money.New(v, "KZT")
+		return &m
+	}
+	converter := &money.Converter{Base: "KZT", Rounding: money.Rounding{Mode: money.RoundHalfUp}}
+
+	tests := []struct {
+		name     string
+		coupon   Coupon
+		shipping int64
+		lines    []int64
+		total    int64
+		err      error
+	}{
+		{name: "percentage", coupon: Coupon{Type: CouponPercentage, Percent: 10, Active: &active}, lines: []int64{100, 300}, total: 3600},
+		{name: "fixed", coupon: Coupon{Type: CouponFixed, Amount: amount(500), Active: &active}, lines: []int64{125, 375}, total: 3500},
+		{name: "fixed capped by subtotal", coupon: Coupon{Type: CouponFixed, Amount: amount(9000), Active: &active}, lines: []int64{1000, 3000}, total: 0},
+		{name: "free shipping", coupon: Coupon{Type: CouponFreeShipping, Active: &active}, shipping: 1500, lines: []int64{0, 0}, total: 4000},
+		{name: "active not loaded", coupon: Coupon{Type: CouponPercentage, Percent: 50}, lines: []int64{500, 1500}, total: 2000},
+		{name: "free shipping already free", coupon: Coupon{Type: CouponFreeShipping, Active: &active}, err: ErrCouponNotApplicable},
+		{name: "inactive", coupon: Coupon{Type: CouponPercentage, Percent: 10, Active: &inactive}, err: ErrCouponNotApplicable},
+		{name: "expired", coupon: Coupon{Type: CouponPercentage, Percent: 10, Active: &active, EndsAt: &past}, err: ErrCouponNotApplicable},
+		{name: "below minimum", coupon: Coupon{Type: CouponPercentage, Percent: 10, Active: &active, MinOrder: amount(5000)}, err: ErrCouponNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBreakdown(1000, 3000)
+			b.Shipping = money.New(tt.shipping, "KZT")
+			b.total()
+			coupon := tt.coupon
+			coupon.Code = "SAVE"
+
+			// Шектеусіз купон базаға жүгінбейді
+			err := b.applyCoupon(nil, converter, &coupon, 1, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("applyCoupon() error = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				if b.Coupon != nil {
+					t.Errorf("Coupon = %+v, want nil", b.Coupon)
+				}
+				return
+			}
+			if got := lineDiscounts(b); !equalInts(got, tt.lines) {
+				t.Errorf("line discounts = %v, want %v", got, tt.lines)
+			}
+			if b.Total != money.New(tt.total, "KZT") {
+				t.Errorf("Total = %v, want %d", b.Total, tt.total)
+			}
+			if b.Coupon == nil || b.Coupon.Code != "SAVE" {
+				t.Errorf("Coupon = %+v", b.Coupon)
+			}
+		})
+	}
+}
@@ -1,20 +1,33 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+
+	"NomadShop/money"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrProductNotFound = errors.New("product not found")
+
 type Product struct {
-	ID          uint     `gorm:"primaryKey"`
-	Name        string   `gorm:"not null"`
-	Price       uint     `gorm:"not null"`
-	Description string   `gorm:"not null"`
-	Image       string   `gorm:"not null"`
-	Color       string   `gorm:"not null"`
-	Size        string   `gorm:"not null"`
-	CategoryID  uint     `gorm:"not null"`
-	Category    Category `gorm:"foreignKey:CategoryID"`
-	Stock       uint     `gorm:"not null"`
+	ID          uint        `gorm:"primaryKey"`
+	Name        string      `gorm:"not null"`
+	Price       money.Money `gorm:"embedded;embeddedPrefix:price_"`
+	Description string      `gorm:"not null"`
+	Image       string      `gorm:"not null"`
+	Color       string      `gorm:"not null"`
+	Size        string      `gorm:"not null"`
+	CategoryID  uint        `gorm:"not null"`
+	Category    Category    `gorm:"foreignKey:CategoryID"`
+	Stock       uint        `gorm:"not null"` // барлық нұсқалар қорының қосындысы
+
+	Variants []ProductVariant `gorm:"foreignKey:ProductID;references:ID" json:",omitempty"`
+	Images   []ProductImage   `gorm:"foreignKey:ProductID;references:ID" json:",omitempty"`
+
+	// SearchRank тек SearchProducts нәтижелерінде толтырылады
+	SearchRank float64 `gorm:"column:search_rank;->" json:",omitempty"`
 }
 
 func GetProducts(db *gorm.DB) ([]Product, error) {
@@ -23,23 +36,154 @@ func GetProducts(db *gorm.DB) ([]Product, error) {
 	return products, err
 }
 
-func CreateProduct(db *gorm.DB, product *Product) (*Product, error) {
-	err := db.Create(&product).Error
+// CreateProduct өнімді нұсқаларымен бірге жасайды. Нұсқалар берілмесе, өнімнің
+// түсі, өлшемі және қоры бар бір әдепкі нұсқа жасалады; әйтпесе бірінші нұсқа әдепкі болады.
+func CreateProduct(db *gorm.DB, settings Settings, product *Product, actorID uint) (*Product, error) {
+	if err := checkPrice(&product.Price, settings.Currency); err != nil {
+		return nil, err
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		variants := product.Variants
+		if len(variants) == 0 {
+			variants = []ProductVariant{{Color: product.Color, Size: product.Size, Image: product.Image, Stock: product.Stock}}
+		}
+
+		// Бастапқы қор да ledger арқылы енгізіледі
+		product.Stock = 0
+		product.Variants = nil
+		// Суреттер тек жүктеу эндпоинті арқылы қосылады
+		product.Images = nil
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+
+		for i := range variants {
+			variants[i].ID = 0
+			variants[i].ProductID = product.ID
+			variants[i].IsDefault = i == 0
+			if err := createVariant(tx, settings, &variants[i], actorID); err != nil {
+				return err
+			}
+			product.Stock += variants[i].Stock
+		}
+		product.Variants = variants
+		return nil
+	})
 	return product, err
 }
 
 func GetProductByID(db *gorm.DB, id uint) (*Product, error) {
 	var product Product
-	err := db.Preload("Category").First(&product, id).Error
+	err := db.Preload("Category").Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Scopes(PreloadImages).First(&product, id).Error
 	return &product, err
 }
 
-func UpdateProduct(db *gorm.DB, id uint, product *Product) (*Product, error) {
-	err := db.Model(&Product{}).Where("id = ?", id).Updates(product).Error
+// UpdateProduct өнімнің өрістерін жаңартады. stock берілсе (нөл де болуы мүмкін), өнімнің
+// жалғыз нұсқасының қоры сол мәнге келтіріліп, айырмасы ledger-ге жазылады: көбейту басым
+// қоймаға түседі, ал азайту қалдығы бар қоймалардан басымдық бойынша алынады.
+func UpdateProduct(db *gorm.DB, settings Settings, id uint, product *Product, stock *uint, actorID uint) (*Product, error) {
+	if product.Price != (money.Money{}) {
+		if err := checkPrice(&product.Price, settings.Currency); err != nil {
+			return nil, err
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, id).Error; err != nil {
+			return err
+		}
+
+		product.Stock = current.Stock
+		if stock != nil && *stock != current.Stock {
+			var variants int64
+			if err := tx.Model(&ProductVariant{}).Where("product_id = ?", id).Count(&variants).Error; err != nil {
+				return err
+			}
+			if variants > 1 {
+				return ErrVariantRequired
+			}
+
+			movements, err := stockAdjustments(tx, id, int64(*stock)-int64(current.Stock))
+			if err != nil {
+				return err
+			}
+			for i := range movements {
+				setMovementActor(&movements[i], actorID)
+				if err := ApplyStockMovement(tx, &movements[i]); err != nil {
+					return err
+				}
+			}
+			product.Stock = *stock
+		}
+
+		return tx.Model(&Product{}).Where("id = ?", id).Omit("stock", clause.Associations).Updates(product).Error
+	})
 	return product, err
 }
 
-func DeleteProduct(db *gorm.DB, id uint) error {
-	err := db.Delete(&Product{}, id).Error
-	return err
+// stockAdjustments өнім қорын delta-ға өзгертетін adjustment қозғалыстарын құрады.
+// Өнім құлыпталған болуы керек: қалдықтарды өзгертетін барлық жолдар алдымен өнімді құлыптайды.
+func stockAdjustments(tx *gorm.DB, productID uint, delta int64) ([]StockMovement, error) {
+	if delta >= 0 {
+		return []StockMovement{{ProductID: productID, Delta: delta, Reason: MovementAdjustment}}, nil
+	}
+
+	var stocks []WarehouseStock
+	if err := tx.Preload("Warehouse").Where("product_id = ? AND quantity > 0", productID).Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	takes, missing := takeStock(stocks, uint(-delta))
+	if missing > 0 {
+		return nil, &StockError{ProductID: productID, Requested: uint(-delta), Available: uint(-delta) - missing}
+	}
+	movements := make([]StockMovement, 0, len(takes))
+	for _, take := range takes {
+		movements = append(movements, StockMovement{
+			ProductID:   productID,
+			VariantID:   take.VariantID,
+			WarehouseID: take.WarehouseID,
+			Delta:       -int64(take.Quantity),
+			Reason:      MovementAdjustment,
+		})
+	}
+	return movements, nil
+}
+
+// DeleteProduct өнімді жояды және оның суреттерін қоймадан өшіру үшін қайтарады
+func DeleteProduct(db *gorm.DB, id uint) ([]ProductImage, error) {
+	var images []ProductImage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Өнім құлыпталғанда оған жаңа сурет қосылмайды
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Find(&[]Product{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("product_id = ?", id).Find(&images).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Product{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// checkPrice валютасы көрсетілмеген бағаны негізгі валютада оқиды және баға теріс емес,
+// әрі дүкеннің негізгі валютасында екенін тексереді. Басқа валюталардағы бағалар тек
+// көрсету кезінде есептеледі.
+func checkPrice(price *money.Money, currency string) error {
+	resolved, err := price.Resolve(currency)
+	if err != nil {
+		return err
+	}
+	if resolved.Currency != currency {
+		return fmt.Errorf("%w: prices must be in %s", money.ErrCurrencyMismatch, currency)
+	}
+	if resolved.IsNegative() {
+		return fmt.Errorf("%w: price cannot be negative", money.ErrInvalidAmount)
+	}
+	*price = resolved
+	return nil
 }
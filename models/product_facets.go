@@ -0,0 +1,162 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+)
+
+const (
+	FacetCategory = "category"
+	FacetColor    = "color"
+	FacetSize     = "size"
+	FacetPrice    = "price"
+	FacetInStock  = "in_stock"
+)
+
+var AllFacets = []string{FacetCategory, FacetColor, FacetSize, FacetPrice, FacetInStock}
+
+// PriceRange [Min, Max) аралығы негізгі валютаның бүтін бірліктерімен (теңге, тиын емес).
+// Max нөл болса, жоғарғы шек жоқ.
+type PriceRange struct {
+	Min uint
+	Max uint
+}
+
+func (r PriceRange) String() string {
+	if r.Max == 0 {
+		return fmt.Sprintf("%d-", r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+var DefaultPriceRanges = []PriceRange{{0, 5000}, {5000, 10000}, {10000, 25000}, {25000, 50000}, {50000, 0}}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// FacetScopes әр facet-ке өз сүзгісінсіз қалған сүзгілерді қолданады,
+// сондықтан "қызыл" таңдалғанда да басқа түстердің саны көрінеді.
+type FacetScopes map[string]func(*gorm.DB) *gorm.DB
+
+// GetProductFacets сұралған facet-тердің мәндері мен сандарын бір сұраумен есептейді.
+// db өнімдерге қойылатын негізгі шарттарды (санат маршруты, іздеу) қамтуы мүмкін.
+func GetProductFacets(db *gorm.DB, settings Settings, facets []string, scopes FacetScopes, ranges []PriceRange) (map[string][]FacetValue, error) {
+	var branches []string
+	var vars []interface{}
+	for _, facet := range facets {
+		branch := db.Session(&gorm.Session{}).Model(&Product{})
+		if scope := scopes[facet]; scope != nil {
+			branch = scope(branch)
+		}
+
+		switch facet {
+		case FacetCategory:
+			branch = branch.Joins("JOIN categories fc ON fc.id = products.category_id").
+				Select("'category' AS facet, products.category_id::text AS value, fc.name AS label, count(*) AS count").
+				Group("products.category_id, fc.name")
+		case FacetColor, FacetSize:
+			// Өнім бір түстің бірнеше өлшемінде болса да бір рет саналады
+			branch = branch.Joins("JOIN product_variants fv ON fv.product_id = products.id").
+				Select("'" + facet + "' AS facet, fv." + facet + " AS value, '' AS label, count(DISTINCT products.id) AS count").
+				Where("fv." + facet + " <> ''").
+				Group("fv." + facet)
+		case FacetPrice:
+			scale, err := money.Scale(settings.Currency)
+			if err != nil {
+				return nil, err
+			}
+			var cases []string
+			var args []interface{}
+			for _, r := range ranges {
+				if r.Max == 0 {
+					cases = append(cases, "WHEN products.price_amount >= ? THEN ?::text")
+					args = append(args, int64(r.Min)*scale, r.String())
+				} else {
+					cases = append(cases, "WHEN products.price_amount >= ? AND products.price_amount < ? THEN ?::text")
+					args = append(args, int64(r.Min)*scale, int64(r.Max)*scale, r.String())
+				}
+			}
+			branch = branch.Select("'price' AS facet, CASE "+strings.Join(cases, " ")+" END AS value, '' AS label, count(*) AS count", args...).
+				Group("value")
+		case FacetInStock:
+			branch = branch.Select("'in_stock' AS facet, (products.stock > 0)::text AS value, '' AS label, count(*) AS count").
+				Group("value")
+		default:
+			return nil, fmt.Errorf("unknown facet %q", facet)
+		}
+		branches = append(branches, "?")
+		vars = append(vars, branch)
+	}
+
+	result := map[string][]FacetValue{}
+	if len(branches) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		Facet string
+		FacetValue
+	}
+	if err := db.Session(&gorm.Session{NewDB: true}).Raw(strings.Join(branches, " UNION ALL "), vars...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, facet := range facets {
+		result[facet] = []FacetValue{}
+	}
+	for _, row := range rows {
+		// Ешбір аралыққа кірмеген бағалар (CASE ... END = NULL) көрсетілмейді
+		if row.Value == "" {
+			continue
+		}
+		result[row.Facet] = append(result[row.Facet], row.FacetValue)
+	}
+
+	for facet, values := range result {
+		switch facet {
+		case FacetPrice:
+			order := map[string]int{}
+			for i, r := range ranges {
+				order[r.String()] = i
+			}
+			sort.Slice(values, func(i, j int) bool { return order[values[i].Value] < order[values[j].Value] })
+		default:
+			sort.Slice(values, func(i, j int) bool {
+				if values[i].Count != values[j].Count {
+					return values[i].Count > values[j].Count
+				}
+				return values[i].Value < values[j].Value
+			})
+		}
+	}
+	return result, nil
+}
+
+// ParsePriceRanges "0-5000,5000-20000,20000-" түріндегі аралықтарды оқиды
+func ParsePriceRanges(raw string) ([]PriceRange, error) {
+	var ranges []PriceRange
+	for _, part := range strings.Split(raw, ",") {
+		var r PriceRange
+		minPart, maxPart, found := strings.Cut(strings.TrimSpace(part), "-")
+		if !found {
+			return nil, fmt.Errorf("invalid price range %q", part)
+		}
+		if _, err := fmt.Sscan(minPart, &r.Min); err != nil {
+			return nil, fmt.Errorf("invalid price range %q", part)
+		}
+		if maxPart != "" {
+			if _, err := fmt.Sscan(maxPart, &r.Max); err != nil || r.Max <= r.Min {
+				return nil, fmt.Errorf("invalid price range %q", part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
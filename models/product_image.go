@@ -0,0 +1,235 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ImagePending = "pending"
+	ImageReady   = "ready"
+	ImageFailed  = "failed"
+)
+
+// Сәтсіз өңделген сурет ImageRetryDelay, одан кейін екі, төрт есе ұзақ күтіп қайта өңделеді.
+// ImageMaxAttempts әрекеттен кейін сурет failed күйіне өтеді.
+const (
+	ImageMaxAttempts = 5
+	ImageRetryDelay  = 5 * time.Minute
+)
+
+var ErrImageNotFound = errors.New("product image not found")
+
+// ProductImage өнімнің суреті. Түпнұсқа жүктелгенде бірден сақталады, ал кішірейтілген
+// өлшемдері мен WebP нұсқаларын фондық жұмыс жасап, URLs-қа қосады
+// (мысалы, "thumb", "thumb_webp"). Әр өнімнің ең көбі бір негізгі суреті болады.
+type ProductImage struct {
+	ID         uint              `gorm:"primaryKey"`
+	ProductID  uint              `gorm:"not null;index"`
+	StorageKey string            `gorm:"not null" json:"-"` // файлдар каталогы, мысалы products/12/3f9a
+	Format     string            `gorm:"not null"`          // түпнұсқаның пішімі: jpeg, png, webp
+	Width      int               `gorm:"not null"`
+	Height     int               `gorm:"not null"`
+	AltText    string            `gorm:"not null"`
+	Position   int               `gorm:"not null"`
+	IsPrimary  bool              `gorm:"not null"`
+	Status     string            `gorm:"not null"`
+	URLs       map[string]string `gorm:"column:urls;type:jsonb;serializer:json;not null"`
+	Files      []string          `gorm:"type:jsonb;serializer:json;not null" json:"-"` // қоймадағы барлық кілттер
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Attempts      int       `gorm:"not null" json:"-"` // өңдеу әрекеттерінің саны
+	NextAttemptAt time.Time `gorm:"not null" json:"-"` // осы уақытқа дейін сурет өңдеуге алынбайды
+}
+
+// ProductImageUpdate өзгертілетін өрістер; nil өрістер өзгермейді.
+// IsPrimary тек true мәнімен қолданылады: басқа сурет негізгі болғанда бұрынғысы алынады.
+type ProductImageUpdate struct {
+	AltText   *string `json:"alt_text"`
+	Position  *int    `json:"position"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+// PreloadImages өнімдердің суреттерін реті бойынша жүктейді
+func PreloadImages(db *gorm.DB) *gorm.DB {
+	return db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	})
+}
+
+func GetProductImages(db *gorm.DB, productID uint) ([]ProductImage, error) {
+	images := []ProductImage{}
+	err := db.Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+// CreateProductImage суретті тізімнің соңына қосады. Өнімнің алғашқы суреті негізгі болады.
+func CreateProductImage(db *gorm.DB, image *ProductImage) (*ProductImage, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Бір өнімге қатар жүктелген суреттер бір позицияны алмауы үшін
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Product{}, image.ProductID).Error; err != nil {
+			return err
+		}
+
+		existing, err := GetProductImages(tx, image.ProductID)
+		if err != nil {
+			return err
+		}
+		image.ID = 0
+		image.Position = len(existing)
+		if len(existing) > 0 {
+			image.Position = existing[len(existing)-1].Position + 1
+		}
+		image.IsPrimary = len(existing) == 0
+		image.Attempts = 0
+		image.NextAttemptAt = time.Now()
+		return tx.Create(image).Error
+	})
+	return image, err
+}
+
+// UpdateProductImage alt мәтінін, ретін және негізгі суретті өзгертеді.
+// Position берілсе, сурет сол орынға қойылып, қалғандары 0-ден бастап қайта нөмірленеді.
+func UpdateProductImage(db *gorm.DB, productID, imageID uint, input *ProductImageUpdate) (*ProductImage, error) {
+	var updated ProductImage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Product{}, productID).Error; err != nil {
+			return err
+		}
+		images, err := GetProductImages(tx, productID)
+		if err != nil {
+			return err
+		}
+		index := slices.IndexFunc(images, func(image ProductImage) bool { return image.ID == imageID })
+		if index < 0 {
+			return ErrImageNotFound
+		}
+		image := images[index]
+
+		if input.AltText != nil {
+			if err := tx.Model(&image).Update("alt_text", *input.AltText).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.IsPrimary != nil && *input.IsPrimary && !image.IsPrimary {
+			// Алдымен бұрынғы негізгі сурет алынады, әйтпесе бірегей индекс бұзылады
+			if err := tx.Model(&ProductImage{}).Where("product_id = ? AND is_primary", productID).Update("is_primary", false).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&image).Update("is_primary", true).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.Position != nil {
+			position := min(max(*input.Position, 0), len(images)-1)
+			images = slices.Delete(images, index, index+1)
+			images = slices.Insert(images, position, image)
+			for i := range images {
+				if images[i].Position == i {
+					continue
+				}
+				if err := tx.Model(&images[i]).Update("position", i).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return tx.First(&updated, imageID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteProductImage суретті жояды және қоймадан өшіру үшін оны қайтарады.
+// Негізгі сурет жойылса, келесі сурет негізгі болады.
+func DeleteProductImage(db *gorm.DB, productID, imageID uint) (*ProductImage, error) {
+	var image ProductImage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Product{}, productID).Error; err != nil {
+			return err
+		}
+		// Фонда өңделіп жатқан суреттің жаңа файлдарын FinishImageProcessing-тен кейін фондық жұмыс өзі өшіреді
+		err := tx.Where("product_id = ?", productID).First(&image, imageID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrImageNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&image).Error; err != nil {
+			return err
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+		var next ProductImage
+		err = tx.Where("product_id = ?", productID).Order("position, id").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ClaimPendingImage өңдеу уақыты келген келесі суретті алады: әрекеттер саны артады, ал келесі
+// әрекет уақыты кейінге жылжиды. Сондықтан өңдеу аяқталмаса (процесс құласа да), сурет сол
+// уақыттан кейін қайта алынады. Сурет жоқ болса, nil қайтарылады.
+func ClaimPendingImage(db *gorm.DB) (*ProductImage, error) {
+	var claimed *ProductImage
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Басқа репликалар алып жатқан суреттер өткізіліп жіберіледі
+		var image ProductImage
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", ImagePending, time.Now()).Order("id").First(&image).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		image.Attempts++
+		image.NextAttemptAt = time.Now().Add(ImageRetryDelay << (image.Attempts - 1))
+		if err := tx.Model(&image).Select("attempts", "next_attempt_at").Updates(&image).Error; err != nil {
+			return err
+		}
+		claimed = &image
+		return nil
+	})
+	return claimed, err
+}
+
+// FinishImageProcessing өңдеу нәтижесін сақтайды. Сурет басқа әрекетке қайта алынған болса,
+// ештеңе өзгермейді; ал өңдеу кезінде жойылған болса, ErrImageNotFound қайтарылады.
+func FinishImageProcessing(db *gorm.DB, image *ProductImage) error {
+	result := db.Model(image).Where("status = ? AND attempts = ?", ImagePending, image.Attempts).
+		Select("status", "urls", "files").Updates(image)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	var count int64
+	if err := db.Model(&ProductImage{}).Where("id = ?", image.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrImageNotFound
+	}
+	return nil
+}
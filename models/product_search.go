@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmptySearchQuery      = errors.New("search query is empty")
+	ErrUnsupportedSearchLang = errors.New("unsupported search language")
+)
+
+// searchConfigs тіл кодтарын PostgreSQL мәтіндік іздеу конфигурацияларына сәйкестендіреді
+var searchConfigs = map[string]string{
+	"ru": "russian",
+	"en": "english",
+	"kk": "kazakh",
+}
+
+// SearchProducts өнімдерді Name мен Description бойынша іздейтін scope қайтарады.
+// Әр сөз префикс ретінде ізделеді (автотолтыру үшін), қате терілген атаулар pg_trgm
+// арқылы табылады. Нәтиже products ретінде берілетін ішкі сұрау, сондықтан оған
+// әдеттегі сүзгілер мен сұрыптауды қолдануға болады; сәйкестік Product.SearchRank-қа жазылады.
+// lang бос болса, барлық тілдер бойынша ізделеді.
+func SearchProducts(q, lang string) (func(*gorm.DB) *gorm.DB, error) {
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	configs := []string{"russian", "english", "kazakh"}
+	if lang != "" {
+		config, ok := searchConfigs[lang]
+		if !ok {
+			return nil, ErrUnsupportedSearchLang
+		}
+		configs = []string{config}
+	}
+
+	// Конфигурация атаулары тек searchConfigs-тен алынады, сондықтан SQL-ге тікелей қойылады
+	var queries []string
+	for _, config := range configs {
+		queries = append(queries, "to_tsquery('"+config+"', @tsquery)")
+	}
+	tsquery := "(" + strings.Join(queries, " || ") + ")"
+
+	args := []interface{}{
+		sql.Named("tsquery", strings.Join(terms, " & ")),
+		sql.Named("q", strings.Join(strings.Fields(q), " ")),
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		search := db.Session(&gorm.Session{NewDB: true}).Model(&Product{}).
+			Select("products.*, (ts_rank(products.search_vector, "+tsquery+") + word_similarity(@q, products.name))::float8 AS search_rank", args...).
+			Where("products.search_vector @@ "+tsquery+" OR @q <% products.name", args...)
+		return db.Table("(?) AS products", search)
+	}, nil
+}
+
+// searchTerms сұрауды tsquery префикс терминдеріне айналдырады: "қызыл көйл" -> қызыл:*, көйл:*
+func searchTerms(q string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, word+":*")
+	}
+	return terms
+}
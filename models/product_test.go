@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"NomadShop/money"
+)
+
+func TestCheckPrice(t *testing.T) {
+	bare := func(raw string) money.Money {
+		var m money.Money
+		if err := json.Unmarshal([]byte(raw), &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		price    money.Money
+		currency string
+		want     money.Money
+		err      error
+	}{
+		{"bare amount in base currency", bare(`"1250.50"`), "KZT", money.New(125050, "KZT"), nil},
+		{"bare amount follows configured currency", bare(`"1250"`), "JPY", money.New(1250, "JPY"), nil},
+		{"omitted price is zero", money.Money{}, "KZT", money.Zero("KZT"), nil},
+		{"explicit base currency", money.New(500, "KZT"), "KZT", money.New(500, "KZT"), nil},
+		{"other currency", money.New(500, "USD"), "KZT", money.Money{}, money.ErrCurrencyMismatch},
+		{"negative", bare(`"-1"`), "KZT", money.Money{}, money.ErrInvalidAmount},
+		{"too many decimals", bare(`"1.005"`), "KZT", money.Money{}, money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		price := tt.price
+		err := checkPrice(&price, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: checkPrice() error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if tt.err == nil && price != tt.want {
+			t.Errorf("%s: price = %#v, want %#v", tt.name, price, tt.want)
+		}
+	}
+}
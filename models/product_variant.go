@@ -0,0 +1,270 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVariantNotFound  = errors.New("product variant not found")
+	ErrVariantRequired  = errors.New("product has several variants, variant must be specified")
+	ErrDefaultVariant   = errors.New("default variant cannot be deleted")
+	ErrVariantInUse     = errors.New("variant has stock or orders")
+	ErrVariantDuplicate = errors.New("variant with these options already exists")
+)
+
+// ProductVariant өнімнің нақты сатылатын нұсқасы (түс, өлшем). Қор, резерв және ledger
+// нұсқа деңгейінде жүргізіледі; Product.Stock барлық нұсқалар қорының қосындысы.
+// Әр өнімнің бір әдепкі нұсқасы бар, ол нұсқа көрсетілмеген сұраныстарда қолданылады.
+type ProductVariant struct {
+	ID        uint         `gorm:"primaryKey"`
+	ProductID uint         `gorm:"not null;index"`
+	SKU       string       `gorm:"not null;unique"`
+	Color     string       `gorm:"not null"`
+	Size      string       `gorm:"not null"`
+	Price     *money.Money `gorm:"embedded;embeddedPrefix:price_"` // nil болса, өнім бағасы қолданылады
+	Image     string       `gorm:"not null"`
+	Stock     uint         `gorm:"not null"`
+	IsDefault bool         `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UnitPrice нұсқаның бағасын қайтарады
+func (v *ProductVariant) UnitPrice(productPrice money.Money) money.Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return productPrice
+}
+
+func GetProductVariants(db *gorm.DB, productID uint) ([]ProductVariant, error) {
+	var variants []ProductVariant
+	err := db.Where("product_id = ?", productID).Order("id").Find(&variants).Error
+	return variants, err
+}
+
+// ResolveVariant өнімнің нұсқасын қайтарады. variantID нөл болса, әдепкі нұсқа алынады.
+func ResolveVariant(db *gorm.DB, productID, variantID uint) (*ProductVariant, error) {
+	var variant ProductVariant
+	query := db.Where("product_id = ?", productID)
+	if variantID != 0 {
+		query = query.Where("id = ?", variantID)
+	} else {
+		query = query.Where("is_default = ?", true)
+	}
+	if err := query.First(&variant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// CreateProductVariant жаңа нұсқа қосады. Бастапқы қор import қозғалысы ретінде жазылады.
+func CreateProductVariant(db *gorm.DB, settings Settings, variant *ProductVariant, actorID uint) (*ProductVariant, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return createVariant(tx, settings, variant, actorID)
+	})
+	return variant, err
+}
+
+func createVariant(tx *gorm.DB, settings Settings, variant *ProductVariant, actorID uint) error {
+	if variant.Price != nil {
+		if err := checkPrice(variant.Price, settings.Currency); err != nil {
+			return err
+		}
+	}
+	initialStock := variant.Stock
+	variant.Stock = 0
+
+	if err := ensureUniqueOptions(tx, variant.ProductID, 0, variant.Color, variant.Size); err != nil {
+		return err
+	}
+	if variant.SKU == "" {
+		variant.SKU = matrixSKU(variant.ProductID, variant.Color, variant.Size)
+	}
+	if err := tx.Create(variant).Error; err != nil {
+		return err
+	}
+
+	movement := StockMovement{ProductID: variant.ProductID, VariantID: variant.ID, Delta: int64(initialStock), Reason: MovementImport, Note: "initial stock"}
+	setMovementActor(&movement, actorID)
+	if err := ApplyStockMovement(tx, &movement); err != nil {
+		return err
+	}
+	variant.Stock = initialStock
+	return nil
+}
+
+// UpdateProductVariant нұсқаны жаңартады. Stock берілсе, айырма adjustment қозғалысы ретінде жазылады.
+func UpdateProductVariant(db *gorm.DB, settings Settings, productID, variantID uint, variant *ProductVariant, actorID uint) (*ProductVariant, error) {
+	if variant.Price != nil {
+		if err := checkPrice(variant.Price, settings.Currency); err != nil {
+			return nil, err
+		}
+	}
+	var updated ProductVariant
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Қор өзгеруі мүмкін, сондықтан өнім нұсқадан бұрын құлыпталады (lockStockRows)
+		if _, _, err := lockStockRows(tx, []uint{productID}, nil); err != nil {
+			return err
+		}
+		current, err := ResolveVariant(tx.Clauses(clause.Locking{Strength: "UPDATE"}), productID, variantID)
+		if err != nil {
+			return err
+		}
+		if err := ensureUniqueOptions(tx, productID, current.ID, variant.Color, variant.Size); err != nil {
+			return err
+		}
+
+		if variant.Stock != 0 && variant.Stock != current.Stock {
+			movement := StockMovement{ProductID: productID, VariantID: current.ID, Delta: int64(variant.Stock) - int64(current.Stock), Reason: MovementAdjustment}
+			setMovementActor(&movement, actorID)
+			if err := ApplyStockMovement(tx, &movement); err != nil {
+				return err
+			}
+		}
+
+		if variant.SKU == "" {
+			variant.SKU = current.SKU
+		}
+		err = tx.Model(&ProductVariant{}).Where("id = ?", current.ID).
+			Select("sku", "color", "size", "price_amount", "price_currency", "image").Updates(variant).Error
+		if err != nil {
+			return err
+		}
+		return tx.First(&updated, current.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteProductVariant қоры жоқ және тапсырыстарда кездеспеген нұсқаны жояды.
+// Себет пен таңдаулылардағы жазбалар ON DELETE CASCADE арқылы жойылады.
+func DeleteProductVariant(db *gorm.DB, productID, variantID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		variant, err := ResolveVariant(tx.Clauses(clause.Locking{Strength: "UPDATE"}), productID, variantID)
+		if err != nil {
+			return err
+		}
+		if variant.IsDefault {
+			return ErrDefaultVariant
+		}
+
+		var ordered int64
+		if err := tx.Model(&OrderItem{}).Where("variant_id = ?", variant.ID).Count(&ordered).Error; err != nil {
+			return err
+		}
+		var movements int64
+		if err := tx.Model(&StockMovement{}).Where("variant_id = ?", variant.ID).Count(&movements).Error; err != nil {
+			return err
+		}
+		if variant.Stock > 0 || ordered > 0 || movements > 0 {
+			return ErrVariantInUse
+		}
+		return tx.Delete(variant).Error
+	})
+}
+
+// GenerateVariantMatrix түстер мен өлшемдердің барлық комбинациялары үшін жетіспейтін нұсқаларды жасайды.
+// Әдепкі нұсқаның опциялары бос болса, ол бірінші комбинацияны алады.
+func GenerateVariantMatrix(db *gorm.DB, settings Settings, productID uint, colors, sizes []string, price *money.Money, actorID uint) ([]ProductVariant, error) {
+	if len(colors) == 0 {
+		colors = []string{""}
+	}
+	if len(sizes) == 0 {
+		sizes = []string{""}
+	}
+
+	var created []ProductVariant
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Бір өнімнің матрицасын қатар екі рет жасамау үшін
+		var product Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return err
+		}
+
+		existing, err := GetProductVariants(tx, productID)
+		if err != nil {
+			return err
+		}
+		type options struct{ color, size string }
+		seen := map[options]bool{}
+		var blankDefault *ProductVariant
+		for i := range existing {
+			seen[options{existing[i].Color, existing[i].Size}] = true
+			if existing[i].IsDefault && existing[i].Color == "" && existing[i].Size == "" {
+				blankDefault = &existing[i]
+			}
+		}
+
+		for _, color := range colors {
+			for _, size := range sizes {
+				color, size := strings.TrimSpace(color), strings.TrimSpace(size)
+				if seen[options{color, size}] {
+					continue
+				}
+				seen[options{color, size}] = true
+
+				if blankDefault != nil {
+					blankDefault.Color, blankDefault.Size = color, size
+					if err := tx.Model(blankDefault).Select("color", "size").Updates(blankDefault).Error; err != nil {
+						return err
+					}
+					created = append(created, *blankDefault)
+					blankDefault = nil
+					continue
+				}
+
+				variant := ProductVariant{
+					ProductID: productID,
+					Color:     color,
+					Size:      size,
+					Price:     price,
+					Image:     product.Image,
+				}
+				if err := createVariant(tx, settings, &variant, actorID); err != nil {
+					return err
+				}
+				created = append(created, variant)
+			}
+		}
+		return nil
+	})
+	return created, err
+}
+
+// matrixSKU нұсқаның опцияларынан SKU құрастырады, мысалы P12-RED-XL
+func matrixSKU(productID uint, color, size string) string {
+	parts := []string{fmt.Sprintf("P%d", productID)}
+	for _, option := range []string{color, size} {
+		if option != "" {
+			parts = append(parts, strings.ToUpper(strings.Join(strings.Fields(option), "_")))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func ensureUniqueOptions(tx *gorm.DB, productID, exceptID uint, color, size string) error {
+	var count int64
+	err := tx.Model(&ProductVariant{}).
+		Where("product_id = ? AND color = ? AND size = ? AND id <> ?", productID, color, size, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrVariantDuplicate
+	}
+	return nil
+}
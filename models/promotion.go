@@ -0,0 +1,345 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+)
+
+const (
+	PromotionBuyXGetY = "buy_x_get_y" // X бірлік сатып алсаң, Y бірлік тегін немесе жеңілдікпен
+	PromotionTiered   = "tiered"      // сома шегінен асса, пайыздық жеңілдік
+	PromotionBundle   = "bundle"      // N бірлік тіркелген бағамен
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrPromotionInvalid  = errors.New("invalid promotion")
+)
+
+// PromotionTier сатылы жеңілдіктің бір сатысы: аядағы тауарлар сомасы MinSubtotal-дан
+// кем болмаса, Percent пайыз жеңілдік беріледі
+type PromotionTier struct {
+	MinSubtotal money.Money `json:"min_subtotal"`
+	Percent     int         `json:"percent"`
+}
+
+// Promotion себетке автоматты түрде қолданылатын акция. Акциялар Priority кемуі бойынша
+// қолданылады, әрқайсысы алдыңғы жеңілдіктерден кейін қалған сомаға. Stackable емес акция
+// басқа акция қолданылмаған болса ғана қолданылады және одан кейін басқа акциялар қолданылмайды.
+// ProductIDs мен CategoryIDs бос болса, акция барлық өнімдерге жатады.
+// Сомалар негізгі валютада беріледі.
+type Promotion struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null"`
+	Type        string `gorm:"not null"` // Promotion* тұрақтыларының бірі
+	Priority    int    `gorm:"not null"`
+	Stackable   bool   `gorm:"not null"`
+	ProductIDs  []uint `gorm:"type:jsonb;serializer:json;not null"`
+	CategoryIDs []uint `gorm:"type:jsonb;serializer:json;not null"`
+
+	BuyQuantity int `gorm:"not null"` // buy_x_get_y: X
+	GetQuantity int `gorm:"not null"` // buy_x_get_y: Y
+	GetPercent  int `gorm:"not null"` // buy_x_get_y: Y бірліктің жеңілдігі, 100 — тегін
+
+	Tiers []PromotionTier `gorm:"type:jsonb;serializer:json;not null"` // tiered
+
+	BundleQuantity int          `gorm:"not null"`                              // bundle: N
+	BundlePrice    *money.Money `gorm:"embedded;embeddedPrefix:bundle_price_"` // bundle: N бірліктің бағасы
+
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+	Active    *bool `gorm:"not null;default:true"` // берілмесе, акция белсенді
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func GetPromotionByID(db *gorm.DB, id uint) (*Promotion, error) {
+	var promotion Promotion
+	if err := db.First(&promotion, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func CreatePromotion(db *gorm.DB, settings Settings, promotion *Promotion) (*Promotion, error) {
+	if err := checkPromotion(db, settings, promotion); err != nil {
+		return nil, err
+	}
+	promotion.ID = 0
+	if err := db.Create(promotion).Error; err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion акцияның барлық өрістерін ауыстырады; Active берілмесе, ол өзгермейді.
+// Бұрынғы тапсырыстардағы жеңілдіктер өзгермейді.
+func UpdatePromotion(db *gorm.DB, settings Settings, id uint, promotion *Promotion) (*Promotion, error) {
+	current, err := GetPromotionByID(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPromotion(db, settings, promotion); err != nil {
+		return nil, err
+	}
+	promotion.ID = current.ID
+	promotion.CreatedAt = current.CreatedAt
+	if promotion.Active == nil {
+		promotion.Active = current.Active
+	}
+	if err := db.Save(promotion).Error; err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func DeletePromotion(db *gorm.DB, id uint) error {
+	result := db.Delete(&Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+// checkPromotion әкімші берген акцияны тексереді; түріне қатысы жоқ өрістер тазаланады
+func checkPromotion(db *gorm.DB, settings Settings, promotion *Promotion) error {
+	promotion.Name = strings.TrimSpace(promotion.Name)
+	if promotion.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrPromotionInvalid)
+	}
+
+	buy, get, percent := promotion.BuyQuantity, promotion.GetQuantity, promotion.GetPercent
+	tiers := promotion.Tiers
+	bundleQuantity, bundlePrice := promotion.BundleQuantity, promotion.BundlePrice
+	promotion.BuyQuantity, promotion.GetQuantity, promotion.GetPercent = 0, 0, 0
+	promotion.Tiers = []PromotionTier{}
+	promotion.BundleQuantity, promotion.BundlePrice = 0, nil
+
+	switch promotion.Type {
+	case PromotionBuyXGetY:
+		if percent == 0 {
+			percent = 100
+		}
+		if buy < 1 || get < 1 || percent < 1 || percent > 100 {
+			return fmt.Errorf("%w: buy and get quantities must be positive and get_percent between 1 and 100", ErrPromotionInvalid)
+		}
+		promotion.BuyQuantity, promotion.GetQuantity, promotion.GetPercent = buy, get, percent
+	case PromotionTiered:
+		if len(tiers) == 0 {
+			return fmt.Errorf("%w: at least one tier is required", ErrPromotionInvalid)
+		}
+		for i := range tiers {
+			if err := checkPrice(&tiers[i].MinSubtotal, settings.Currency); err != nil {
+				return err
+			}
+		}
+		sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinSubtotal.Amount < tiers[j].MinSubtotal.Amount })
+		for i, tier := range tiers {
+			if tier.Percent < 1 || tier.Percent > 100 {
+				return fmt.Errorf("%w: tier percent must be between 1 and 100", ErrPromotionInvalid)
+			}
+			if i > 0 && tier.MinSubtotal.Amount == tiers[i-1].MinSubtotal.Amount {
+				return fmt.Errorf("%w: tier thresholds must be unique", ErrPromotionInvalid)
+			}
+		}
+		promotion.Tiers = tiers
+	case PromotionBundle:
+		if bundlePrice != nil {
+			if err := checkPrice(bundlePrice, settings.Currency); err != nil {
+				return err
+			}
+		}
+		if bundleQuantity < 2 || bundlePrice == nil || bundlePrice.Amount <= 0 {
+			return fmt.Errorf("%w: bundles need at least 2 items and a positive price", ErrPromotionInvalid)
+		}
+		promotion.BundleQuantity, promotion.BundlePrice = bundleQuantity, bundlePrice
+	default:
+		return fmt.Errorf("%w: type must be %s, %s or %s", ErrPromotionInvalid, PromotionBuyXGetY, PromotionTiered, PromotionBundle)
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrPromotionInvalid)
+	}
+	if promotion.ProductIDs == nil {
+		promotion.ProductIDs = []uint{}
+	}
+	if promotion.CategoryIDs == nil {
+		promotion.CategoryIDs = []uint{}
+	}
+	return checkScope(db, promotion.ProductIDs, promotion.CategoryIDs, ErrPromotionInvalid)
+}
+
+// activePromotions қазір жарамды акцияларды қолдану ретімен қайтарады
+func activePromotions(db *gorm.DB, now time.Time) ([]Promotion, error) {
+	var promotions []Promotion
+	err := db.Where("active AND (starts_at IS NULL OR starts_at <= ?) AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Order("priority DESC, id").Find(&promotions).Error
+	return promotions, err
+}
+
+// applyPromotions жарамды акцияларды басымдығы бойынша себетке қолданады
+func (b *PriceBreakdown) applyPromotions(db *gorm.DB, converter *money.Converter, now time.Time) error {
+	promotions, err := activePromotions(db, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range promotions {
+		promotion := &promotions[i]
+		if len(b.Promotions) > 0 && !promotion.Stackable {
+			continue
+		}
+		lines, err := b.eligibleLines(db, promotion.ProductIDs, promotion.CategoryIDs)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		source := LineDiscount{Source: DiscountPromotion, SourceID: promotion.ID, Name: promotion.Name}
+		var discount int64
+		switch promotion.Type {
+		case PromotionBuyXGetY:
+			discount = b.applyBuyXGetY(promotion, lines, source)
+		case PromotionTiered:
+			if discount, err = b.applyTiered(promotion, lines, converter, source); err != nil {
+				return err
+			}
+		case PromotionBundle:
+			if discount, err = b.applyBundle(promotion, lines, converter, source); err != nil {
+				return err
+			}
+		}
+		if discount == 0 {
+			continue
+		}
+
+		b.Promotions = append(b.Promotions, AppliedPromotion{
+			ID:       promotion.ID,
+			Name:     promotion.Name,
+			Type:     promotion.Type,
+			Discount: money.New(discount, b.Currency),
+		})
+		if !promotion.Stackable {
+			break
+		}
+	}
+
+	b.total()
+	return nil
+}
+
+// units жолдардың бірліктерін бағасы бойынша сұрыптайды (cheapestFirst болса, арзаны бірінші)
+// және бірінші count бірліктің әр жолдан қаншасы алынғанын қайтарады
+func (b *PriceBreakdown) units(lines []int, count int64, cheapestFirst bool) []int64 {
+	order := make([]int, len(lines))
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(x, y int) bool {
+		left, right := b.Lines[lines[order[x]]].UnitPrice.Amount, b.Lines[lines[order[y]]].UnitPrice.Amount
+		if cheapestFirst {
+			return left < right
+		}
+		return left > right
+	})
+
+	taken := make([]int64, len(lines))
+	for _, k := range order {
+		if count == 0 {
+			break
+		}
+		taken[k] = min(count, int64(b.Lines[lines[k]].Quantity))
+		count -= taken[k]
+	}
+	return taken
+}
+
+func (b *PriceBreakdown) totalQuantity(lines []int) int64 {
+	var quantity int64
+	for _, i := range lines {
+		quantity += int64(b.Lines[i].Quantity)
+	}
+	return quantity
+}
+
+// applyBuyXGetY әр X+Y бірліктің ең арзан Y бірлігіне жеңілдік береді
+func (b *PriceBreakdown) applyBuyXGetY(promotion *Promotion, lines []int, source LineDiscount) int64 {
+	groups := b.totalQuantity(lines) / int64(promotion.BuyQuantity+promotion.GetQuantity)
+	if groups == 0 {
+		return 0
+	}
+	free := b.units(lines, groups*int64(promotion.GetQuantity), true)
+
+	var applied int64
+	for k, i := range lines {
+		if free[k] == 0 {
+			continue
+		}
+		line := &b.Lines[i]
+		amount := min(percentOf(line.UnitPrice.Amount*free[k], promotion.GetPercent), line.remaining())
+		if amount > 0 {
+			line.addDiscount(source, amount)
+			applied += amount
+		}
+	}
+	return applied
+}
+
+// applyTiered аядағы тауарлар сомасы жеткен ең жоғары сатының пайызын қолданады
+func (b *PriceBreakdown) applyTiered(promotion *Promotion, lines []int, converter *money.Converter, source LineDiscount) (int64, error) {
+	subtotal := b.remainingOf(lines)
+	percent := 0
+	for _, tier := range promotion.Tiers {
+		threshold, err := converter.Convert(tier.MinSubtotal, b.Currency)
+		if err != nil {
+			return 0, err
+		}
+		if subtotal >= threshold.Amount {
+			percent = tier.Percent
+		}
+	}
+	if percent == 0 {
+		return 0, nil
+	}
+	return b.allocate(lines, b.remainingWeights(lines), percentOf(subtotal, percent), source), nil
+}
+
+// applyBundle ең қымбат бірліктерден N-нен топтар құрап, әр топты BundlePrice бағасына сатады
+func (b *PriceBreakdown) applyBundle(promotion *Promotion, lines []int, converter *money.Converter, source LineDiscount) (int64, error) {
+	groups := b.totalQuantity(lines) / int64(promotion.BundleQuantity)
+	if groups == 0 {
+		return 0, nil
+	}
+	price, err := converter.Convert(*promotion.BundlePrice, b.Currency)
+	if err != nil {
+		return 0, err
+	}
+
+	bundled := b.units(lines, groups*int64(promotion.BundleQuantity), false)
+	weights := make([]int64, len(lines))
+	var value int64
+	for k, i := range lines {
+		weights[k] = b.Lines[i].UnitPrice.Amount * bundled[k]
+		value += weights[k]
+	}
+	// Жиынтық бағасы тауарлардың өз бағасынан қымбат болса, акция қолданылмайды
+	discount := value - price.Amount*groups
+	if discount <= 0 {
+		return 0, nil
+	}
+	return b.allocate(lines, weights, discount, source), nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"testing"
+
+	"NomadShop/money"
+)
+
+// unitsBreakdown әр жұп үшін {бірлік бағасы, саны} жолы бар есеп жасайды
+func unitsBreakdown(units ...[2]int64) (*PriceBreakdown, []int) {
+	b := testBreakdown()
+	lines := make([]int, len(units))
+	for i, u := range units {
+		price := money.New(u[0], "KZT")
+		b.Lines = append(b.Lines, PricedLine{
+			ProductID: uint(i + 1),
+			VariantID: uint(i + 1),
+			Quantity:  uint(u[1]),
+			UnitPrice: price,
+			Total:     price.Mul(u[1]),
+			Discount:  money.Zero("KZT"),
+		})
+		lines[i] = i
+	}
+	b.total()
+	return b, lines
+}
+
+func TestApplyBuyXGetY(t *testing.T) {
+	tests := []struct {
+		name      string
+		buy, get  int
+		percent   int
+		units     [][2]int64
+		discounts []int64
+	}{
+		{name: "one free unit", buy: 2, get: 1, percent: 100, units: [][2]int64{{1000, 3}}, discounts: []int64{1000}},
+		{name: "cheapest unit is free", buy: 2, get: 1, percent: 100, units: [][2]int64{{1000, 2}, {500, 1}}, discounts: []int64{0, 500}},
+		{name: "half price", buy: 1, get: 1, percent: 50, units: [][2]int64{{1000, 4}}, discounts: []int64{1000}},
+		{name: "not enough units", buy: 2, get: 1, percent: 100, units: [][2]int64{{1000, 2}}, discounts: []int64{0}},
+		{name: "several groups from one line", buy: 1, get: 1, percent: 100, units: [][2]int64{{300, 1}, {200, 1}, {100, 2}}, discounts: []int64{0, 0, 200}},
+		{name: "groups span lines", buy: 1, get: 1, percent: 100, units: [][2]int64{{300, 3}, {100, 1}}, discounts: []int64{300, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, lines := unitsBreakdown(tt.units...)
+			promotion := &Promotion{Type: PromotionBuyXGetY, BuyQuantity: tt.buy, GetQuantity: tt.get, GetPercent: tt.percent}
+
+			applied := b.applyBuyXGetY(promotion, lines, LineDiscount{Source: DiscountPromotion, SourceID: 1})
+			checkPromotionDiscounts(t, b, applied, tt.discounts)
+		})
+	}
+}
+
+func TestApplyTiered(t *testing.T) {
+	promotion := &Promotion{Type: PromotionTiered, Tiers: []PromotionTier{
+		{MinSubtotal: money.New(10000, "KZT"), Percent: 5},
+		{MinSubtotal: money.New(20000, "KZT"), Percent: 10},
+	}}
+	converter := &money.Converter{Base: "KZT", Rounding: money.Rounding{Mode: money.RoundHalfUp}}
+
+	tests := []struct {
+		name      string
+		units     [][2]int64
+		discounts []int64
+	}{
+		{name: "below first tier", units: [][2]int64{{9999, 1}}, discounts: []int64{0}},
+		{name: "first tier", units: [][2]int64{{15000, 1}}, discounts: []int64{750}},
+		{name: "exactly on threshold", units: [][2]int64{{5000, 2}}, discounts: []int64{500}},
+		{name: "highest reached tier", units: [][2]int64{{10000, 1}, {15000, 1}}, discounts: []int64{1000, 1500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, lines := unitsBreakdown(tt.units...)
+			applied, err := b.applyTiered(promotion, lines, converter, LineDiscount{Source: DiscountPromotion, SourceID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPromotionDiscounts(t, b, applied, tt.discounts)
+		})
+	}
+}
+
+func TestApplyBundle(t *testing.T) {
+	price := money.New(2500, "KZT")
+	promotion := &Promotion{Type: PromotionBundle, BundleQuantity: 3, BundlePrice: &price}
+	converter := &money.Converter{Base: "KZT", Rounding: money.Rounding{Mode: money.RoundHalfUp}}
+
+	tests := []struct {
+		name      string
+		units     [][2]int64
+		discounts []int64
+	}{
+		{name: "one bundle", units: [][2]int64{{1000, 3}}, discounts: []int64{500}},
+		{name: "two bundles and a spare unit", units: [][2]int64{{1000, 7}}, discounts: []int64{1000}},
+		// Жиынтыққа ең қымбат үш бірлік кіреді: 2×1500 және 1×500
+		{name: "most expensive units bundled", units: [][2]int64{{1500, 2}, {500, 2}}, discounts: []int64{857, 143}},
+		{name: "bundle dearer than items", units: [][2]int64{{500, 3}}, discounts: []int64{0}},
+		{name: "not enough units", units: [][2]int64{{1000, 2}}, discounts: []int64{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, lines := unitsBreakdown(tt.units...)
+			applied, err := b.applyBundle(promotion, lines, converter, LineDiscount{Source: DiscountPromotion, SourceID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPromotionDiscounts(t, b, applied, tt.discounts)
+		})
+	}
+}
+
+func checkPromotionDiscounts(t *testing.T, b *PriceBreakdown, applied int64, want []int64) {
+	t.Helper()
+	var sum int64
+	for _, discount := range want {
+		sum += discount
+	}
+	if applied != sum {
+		t.Errorf("applied = %d, want %d", applied, sum)
+	}
+	if got := lineDiscounts(b); !equalInts(got, want) {
+		t.Errorf("line discounts = %v, want %v", got, want)
+	}
+}
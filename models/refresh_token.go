@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// RefreshToken бір кіру сессиясының (FamilyID) ротацияланатын токені
+type RefreshToken struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"not null;index"`
+	FamilyID     string    `gorm:"not null;index"`
+	TokenHash    string    `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null"`
+	RevokedAt    *time.Time
+	ReplacedByID *uint
+	CreatedAt    time.Time
+	User         User `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+func CreateRefreshToken(db *gorm.DB, userID uint, familyID, tokenHash string, expiresAt time.Time) (*RefreshToken, error) {
+	token := RefreshToken{UserID: userID, FamilyID: familyID, TokenHash: tokenHash, ExpiresAt: expiresAt}
+	err := db.Create(&token).Error
+	return &token, err
+}
+
+// RotateRefreshToken ескі токенді жаңасымен ауыстырады.
+// Бұрын ауыстырылған токен қайта ұсынылса, бүкіл сессия жойылады.
+func RotateRefreshToken(db *gorm.DB, oldHash, newHash string, expiresAt time.Time) (*RefreshToken, error) {
+	var rotated *RefreshToken
+	reused := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("token_hash = ?", oldHash).First(&current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			reused = true
+			return revokeRefreshTokenFamily(tx, current.FamilyID)
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return ErrRefreshTokenInvalid
+		}
+
+		next := RefreshToken{UserID: current.UserID, FamilyID: current.FamilyID, TokenHash: newHash, ExpiresAt: expiresAt}
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&current).Updates(map[string]interface{}{"revoked_at": now, "replaced_by_id": next.ID}).Error; err != nil {
+			return err
+		}
+		rotated = &next
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return rotated, nil
+}
+
+// RevokeRefreshToken токен жататын сессияны толығымен жабады
+func RevokeRefreshToken(db *gorm.DB, tokenHash string) error {
+	var token RefreshToken
+	if err := db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+	return revokeRefreshTokenFamily(db, token.FamilyID)
+}
+
+func revokeRefreshTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
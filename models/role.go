@@ -4,9 +4,16 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	RoleAdmin    = "admin"
+	RoleManager  = "manager"
+	RoleCustomer = "customer"
+)
+
 type Role struct {
-	ID   uint   `gorm:"primaryKey"`
-	Name string `gorm:"not null"`
+	ID          uint         `gorm:"primaryKey"`
+	Name        string       `gorm:"not null"`
+	Permissions []Permission `gorm:"many2many:role_permissions;"`
 }
 
 func GetRoles(db *gorm.DB) ([]Role, error) {
@@ -22,7 +29,7 @@ func CreateRole(db *gorm.DB, role *Role) (*Role, error) {
 
 func GetRoleByID(db *gorm.DB, id uint) (*Role, error) {
 	var role Role
-	err := db.First(&role, id).Error
+	err := db.Preload("Permissions").First(&role, id).Error
 	return &role, err
 }
 
@@ -32,6 +39,19 @@ func UpdateRole(db *gorm.DB, id uint, role *Role) (*Role, error) {
 }
 
 func DeleteRole(db *gorm.DB, id uint) error {
-	err := db.Delete(&Role{}, id).Error
-	return err
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Role{ID: id}).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&Role{}, id).Error
+	})
+}
+
+func GetRoleByName(db *gorm.DB, name string) (*Role, error) {
+	var role Role
+	err := db.Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
 }
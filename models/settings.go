@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"NomadShop/money"
+)
+
+// Settings модель функцияларына берілетін дүкен баптаулары. main оны конфигурациядан
+// құрып, хендлерлерге береді; тесттер өз мәндерін береді.
+type Settings struct {
+	Currency       string         // негізгі валюта: тауар бағалары осы валютада сақталады
+	Rounding       money.Rounding // бағаларды басқа валютада көрсеткенде қолданылады
+	ShippingRate   money.Money    // бір тапсырысты жеткізу құны негізгі валютада; нөл болса, тегін
+	TaxRate        int64          // базистік пунктпен (1200 = 12%)
+	TaxIncluded    bool           // салық бағаға кірген және Total-ға қосылмайды
+	ReservationTTL time.Duration  // төленбеген тапсырыс үшін қор қанша уақыт ұсталады
+	GuestCartTTL   time.Duration  // соңғы қолданыстан кейін қонақ себеті қанша уақыт сақталады
+}
+
+// DefaultSettings конфигурацияның әдепкі мәндеріне сәйкес келеді
+var DefaultSettings = Settings{
+	Currency:       "KZT",
+	Rounding:       money.Rounding{Mode: money.RoundHalfUp},
+	ShippingRate:   money.Zero("KZT"),
+	ReservationTTL: 30 * time.Minute,
+	GuestCartTTL:   30 * 24 * time.Hour,
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MovementSale       = "sale"
+	MovementReturn     = "return"
+	MovementAdjustment = "adjustment"
+	MovementImport     = "import"
+)
+
+// StockMovement қордың әр өзгерісінің жазбасы.
+// Өнім, нұсқа және қойма қалдықтары әрқашан тиісті қозғалыстардың қосындысына тең болуы керек.
+type StockMovement struct {
+	ID          uint   `gorm:"primaryKey"`
+	ProductID   uint   `gorm:"not null;index"`
+	VariantID   uint   `gorm:"not null;index"`
+	WarehouseID uint   `gorm:"not null"`
+	Delta       int64  `gorm:"not null"`
+	Reason      string `gorm:"not null"`
+	OrderID     *uint
+	UserID      *uint
+	Note        string
+	CreatedAt   time.Time
+}
+
+// StockDrift ledger бойынша есептелген қор мен нақты қордың айырмашылығы.
+// VariantID нөл болса, өнімнің жалпы қоры (Product.Stock) салыстырылған;
+// WarehouseID нөл болса, нұсқаның жалпы қоры (ProductVariant.Stock).
+type StockDrift struct {
+	ProductID   uint
+	VariantID   uint
+	WarehouseID uint
+	Name        string
+	Stock       int64
+	LedgerStock int64
+}
+
+func IsMovementReason(reason string) bool {
+	switch reason {
+	case MovementSale, MovementReturn, MovementAdjustment, MovementImport:
+		return true
+	}
+	return false
+}
+
+// ApplyStockMovement қойма қалдығын, нұсқа мен өнімнің жалпы қорын өзгертіп, ledger-ге жазады.
+// Нұсқа көрсетілмесе, әдепкі нұсқа, қойма көрсетілмесе, басым қойма қолданылады.
+// Транзакция ішінде шақырылуы керек. Азайту шартты түрде орындалады, сондықтан қор теріс бола алмайды.
+func ApplyStockMovement(tx *gorm.DB, movement *StockMovement) error {
+	if movement.Delta == 0 {
+		return nil
+	}
+
+	variant, err := ResolveVariant(tx, movement.ProductID, movement.VariantID)
+	if err != nil {
+		if errors.Is(err, ErrVariantNotFound) && movement.VariantID == 0 {
+			return ErrProductUnavailable
+		}
+		return err
+	}
+	movement.VariantID = variant.ID
+	if _, _, err := lockStockRows(tx, []uint{movement.ProductID}, []uint{movement.VariantID}); err != nil {
+		return err
+	}
+
+	if movement.WarehouseID == 0 {
+		warehouseID, err := primaryWarehouseID(tx)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = warehouseID
+	}
+
+	if movement.Delta < 0 {
+		result := tx.Model(&WarehouseStock{}).
+			Where("warehouse_id = ? AND variant_id = ? AND quantity >= ?", movement.WarehouseID, movement.VariantID, -movement.Delta).
+			UpdateColumn("quantity", gorm.Expr("quantity + ?", movement.Delta))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var stock WarehouseStock
+			err := tx.Where("warehouse_id = ? AND variant_id = ?", movement.WarehouseID, movement.VariantID).First(&stock).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			return &StockError{ProductID: movement.ProductID, VariantID: movement.VariantID, Requested: uint(-movement.Delta), Available: stock.Quantity}
+		}
+	} else {
+		stock := WarehouseStock{WarehouseID: movement.WarehouseID, ProductID: movement.ProductID, VariantID: movement.VariantID, Quantity: uint(movement.Delta)}
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "variant_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"quantity": gorm.Expr("warehouse_stocks.quantity + excluded.quantity")}),
+		}).Create(&stock).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Model(&ProductVariant{}).Where("id = ?", movement.VariantID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", movement.Delta)).Error
+	if err != nil {
+		return err
+	}
+
+	result := tx.Model(&Product{}).Where("id = ?", movement.ProductID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", movement.Delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProductUnavailable
+	}
+
+	return tx.Create(movement).Error
+}
+
+func GetStockMovements(db *gorm.DB, productID uint) ([]StockMovement, error) {
+	var movements []StockMovement
+	err := db.Where("product_id = ?", productID).Order("created_at DESC, id DESC").Find(&movements).Error
+	return movements, err
+}
+
+// FindStockDrift қоры ledger қосындысына сәйкес келмейтін өнімдерді, нұсқаларды және қойма қалдықтарын табады
+func FindStockDrift(db *gorm.DB) ([]StockDrift, error) {
+	var drifts []StockDrift
+	err := db.Raw(`
+		SELECT p.id AS product_id, 0 AS variant_id, 0 AS warehouse_id, p.name, p.stock, COALESCE(SUM(m.delta), 0)::bigint AS ledger_stock
+		FROM products p
+		LEFT JOIN stock_movements m ON m.product_id = p.id
+		GROUP BY p.id, p.name, p.stock
+		HAVING p.stock <> COALESCE(SUM(m.delta), 0)
+		UNION ALL
+		SELECT p.id, v.id, 0, p.name || ' (' || v.sku || ')', v.stock, COALESCE(SUM(m.delta), 0)::bigint
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN stock_movements m ON m.variant_id = v.id
+		GROUP BY p.id, v.id, p.name, v.sku, v.stock
+		HAVING v.stock <> COALESCE(SUM(m.delta), 0)
+		UNION ALL
+		SELECT v.product_id, v.id, w.id, p.name || ' (' || v.sku || ')', COALESCE(ws.quantity, 0), COALESCE(l.ledger_stock, 0)
+		FROM (SELECT variant_id, warehouse_id, SUM(delta)::bigint AS ledger_stock
+		      FROM stock_movements GROUP BY variant_id, warehouse_id) l
+		FULL JOIN warehouse_stocks ws ON ws.variant_id = l.variant_id AND ws.warehouse_id = l.warehouse_id
+		JOIN product_variants v ON v.id = COALESCE(ws.variant_id, l.variant_id)
+		JOIN products p ON p.id = v.product_id
+		JOIN warehouses w ON w.id = COALESCE(ws.warehouse_id, l.warehouse_id)
+		WHERE COALESCE(ws.quantity, 0) <> COALESCE(l.ledger_stock, 0)
+		ORDER BY product_id, variant_id, warehouse_id`).Scan(&drifts).Error
+	return drifts, err
+}
+
+// ReconcileStock қойма қалдықтарын, нұсқа мен өнім қорын ledger бойынша қайта есептеп, айырмашылықтарды түзетеді
+func ReconcileStock(db *gorm.DB) ([]StockDrift, error) {
+	var drifts []StockDrift
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE products, product_variants, warehouse_stocks, stock_movements IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+
+		var err error
+		drifts, err = FindStockDrift(tx)
+		if err != nil {
+			return err
+		}
+		for _, drift := range drifts {
+			if drift.LedgerStock < 0 {
+				return fmt.Errorf("ledger stock for product %d is negative, manual correction required", drift.ProductID)
+			}
+
+			switch {
+			case drift.VariantID == 0:
+				err = tx.Model(&Product{}).Where("id = ?", drift.ProductID).UpdateColumn("stock", drift.LedgerStock).Error
+			case drift.WarehouseID == 0:
+				err = tx.Model(&ProductVariant{}).Where("id = ?", drift.VariantID).UpdateColumn("stock", drift.LedgerStock).Error
+			default:
+				stock := WarehouseStock{WarehouseID: drift.WarehouseID, ProductID: drift.ProductID, VariantID: drift.VariantID, Quantity: uint(drift.LedgerStock)}
+				err = tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "variant_id"}},
+					DoUpdates: clause.AssignmentColumns([]string{"quantity"}),
+				}).Create(&stock).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return drifts, err
+}
+
+func setMovementActor(movement *StockMovement, actorID uint) {
+	if actorID != 0 {
+		movement.UserID = &actorID
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"NomadShop/auth"
+	"errors"
 	"gorm.io/gorm"
 )
 
@@ -8,11 +10,30 @@ type User struct {
 	ID       uint   `gorm:"primaryKey"`
 	Username string `gorm:"not null;unique"`
 	Email    string `gorm:"not null;unique"`
-	Password string `gorm:"not null"`
+	Password string `gorm:"not null" json:"-"`
+
+	CartRemindersOptOut bool `gorm:"not null"` // себет туралы еске салуларды алмайды
 }
 
 func CreateUser(db *gorm.DB, user *User) (*User, error) {
-	err := db.Create(&user).Error
+	if err := hashUserPassword(user); err != nil {
+		return nil, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// Жаңа пайдаланушыға әдепкі "customer" рөлін беру
+		role, err := GetRoleByName(tx, RoleCustomer)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Create(&UserRole{UserID: user.ID, RoleID: role.ID}).Error
+	})
 	return user, err
 }
 
@@ -34,6 +55,11 @@ func UpdateUser(db *gorm.DB, id uint, updatedUser *User) (*User, error) {
 	if err != nil {
 		return nil, err
 	}
+	if updatedUser.Password != "" {
+		if err := hashUserPassword(updatedUser); err != nil {
+			return nil, err
+		}
+	}
 	// Қолданушыны жаңарту
 	err = db.Model(&user).Updates(updatedUser).Error
 	return &user, err
@@ -43,3 +69,64 @@ func DeleteUser(db *gorm.DB, id uint) error {
 	err := db.Delete(&User{}, id).Error
 	return err
 }
+
+// GetUserByLogin пайдаланушыны username немесе email бойынша іздейді
+func GetUserByLogin(db *gorm.DB, login string) (*User, error) {
+	var user User
+	err := db.Where("username = ? OR email = ?", login, login).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CheckUserPassword құпиясөзді тексереді және параметрлер ескірген болса, хэшті жаңартады
+func CheckUserPassword(db *gorm.DB, user *User, password string) (bool, error) {
+	ok, err := auth.VerifyPassword(user.Password, password)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if auth.NeedsRehash(user.Password) {
+		hash, err := auth.HashPassword(password)
+		if err != nil {
+			return true, err
+		}
+		if err := db.Model(user).Update("password", hash).Error; err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// HashPlaintextPasswords хэштелмеген құпиясөздерді бір рет хэштейді
+func HashPlaintextPasswords(db *gorm.DB) (int, error) {
+	var users []User
+	updated := 0
+	result := db.FindInBatches(&users, 100, func(tx *gorm.DB, batch int) error {
+		for i := range users {
+			if auth.IsPasswordHash(users[i].Password) {
+				continue
+			}
+			hash, err := auth.HashPassword(users[i].Password)
+			if err != nil {
+				return err
+			}
+			if err := db.Model(&users[i]).Update("password", hash).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, result.Error
+}
+
+func hashUserPassword(user *User) error {
+	hash, err := auth.HashPassword(user.Password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	return nil
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"NomadShop/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNoWarehouse = errors.New("no active warehouse configured")
+
+// Warehouse тауар жөнелтілетін қойма. Priority кіші болған сайын қойма бірінші таңдалады.
+type Warehouse struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"not null;unique"`
+	City      string `gorm:"not null"`
+	Priority  int    `gorm:"not null"`
+	Active    *bool  `gorm:"not null;default:true"` // берілмесе, қойма белсенді
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WarehouseStock өнім нұсқасының бір қоймадағы қалдығы.
+// ProductVariant.Stock барлық қоймалардағы қалдықтардың қосындысына тең.
+type WarehouseStock struct {
+	ID          uint      `gorm:"primaryKey"`
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_warehouse_stocks_warehouse_variant"`
+	ProductID   uint      `gorm:"not null;index"`
+	VariantID   uint      `gorm:"not null;uniqueIndex:idx_warehouse_stocks_warehouse_variant"`
+	Quantity    uint      `gorm:"not null"`
+	Warehouse   Warehouse `gorm:"foreignKey:WarehouseID;references:ID"`
+}
+
+func GetWarehouses(db *gorm.DB) ([]Warehouse, error) {
+	var warehouses []Warehouse
+	err := db.Order("priority, id").Find(&warehouses).Error
+	return warehouses, err
+}
+
+func GetWarehouseByID(db *gorm.DB, id uint) (*Warehouse, error) {
+	var warehouse Warehouse
+	err := db.First(&warehouse, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+func CreateWarehouse(db *gorm.DB, warehouse *Warehouse) (*Warehouse, error) {
+	err := db.Create(&warehouse).Error
+	return warehouse, err
+}
+
+// UpdateWarehouse қойманы жаңартады; Active берілмесе, ол өзгермейді
+func UpdateWarehouse(db *gorm.DB, id uint, warehouse *Warehouse) (*Warehouse, error) {
+	columns := []string{"name", "city", "priority"}
+	if warehouse.Active != nil {
+		columns = append(columns, "active")
+	}
+	err := db.Model(&Warehouse{}).Where("id = ?", id).Select(columns).Updates(warehouse).Error
+	if err != nil {
+		return nil, err
+	}
+	return GetWarehouseByID(db, id)
+}
+
+func GetWarehouseStocks(db *gorm.DB, productID uint) ([]WarehouseStock, error) {
+	var stocks []WarehouseStock
+	err := db.Preload("Warehouse").Where("product_id = ?", productID).Order("variant_id, warehouse_id").Find(&stocks).Error
+	return stocks, err
+}
+
+// primaryWarehouseID қоймасы көрсетілмеген қозғалыстар үшін басым қойманы қайтарады
+func primaryWarehouseID(tx *gorm.DB) (uint, error) {
+	var warehouse Warehouse
+	err := tx.Where("active = ?", true).Order("priority, id").First(&warehouse).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrNoWarehouse
+	}
+	return warehouse.ID, err
+}
+
+// allocateOrderItems нұсқасы анықталған тапсырыс жолдарын қоймаларға бөледі: алдымен сатып алушының
+// қаласындағы қоймалар, содан кейін басымдық бойынша. Бір қойма жолды толық жаба алмаса,
+// жол бірнеше OrderItem-ге бөлінеді. Транзакция ішінде шақырылуы керек.
+func allocateOrderItems(tx *gorm.DB, items []OrderItem, city string) ([]OrderItem, error) {
+	var warehouses []Warehouse
+	if err := tx.Where("active = ?", true).Order("priority, id").Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	if len(warehouses) == 0 {
+		return nil, ErrNoWarehouse
+	}
+	// Сатып алушыға ең жақын (сол қаладағы) қоймалар бірінші
+	sort.SliceStable(warehouses, func(i, j int) bool {
+		return city != "" && warehouses[i].City == city && warehouses[j].City != city
+	})
+
+	var variantIDs []uint
+	for _, item := range items {
+		variantIDs = append(variantIDs, item.VariantID)
+	}
+
+	// Deadlock болмас үшін қалдықтар әрқашан бір ретпен құлыпталады
+	var stocks []WarehouseStock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("variant_id IN ?", variantIDs).Order("variant_id, warehouse_id").Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	type stockKey struct{ warehouseID, variantID uint }
+	available := map[stockKey]uint{}
+	for _, stock := range stocks {
+		available[stockKey{stock.WarehouseID, stock.VariantID}] = stock.Quantity
+	}
+
+	var allocated []OrderItem
+	for _, item := range items {
+		remaining := item.Quantity
+		for _, warehouse := range warehouses {
+			if remaining == 0 {
+				break
+			}
+			key := stockKey{warehouse.ID, item.VariantID}
+			quantity := min(remaining, available[key])
+			if quantity == 0 {
+				continue
+			}
+			warehouseID := warehouse.ID
+			line := item
+			line.Quantity = quantity
+			line.WarehouseID = &warehouseID
+			line.Discount, line.Discounts, item.Discount, item.Discounts = splitDiscounts(item.Price.Currency, item.Discounts, quantity, remaining)
+			allocated = append(allocated, line)
+			available[key] -= quantity
+			remaining -= quantity
+		}
+
+		if remaining > 0 {
+			return nil, &StockError{ProductID: item.ProductID, VariantID: item.VariantID, Requested: item.Quantity, Available: item.Quantity - remaining}
+		}
+	}
+	return allocated, nil
+}
+
+// stockTake бір қойма қалдығынан алынатын саны
+type stockTake struct {
+	WarehouseID uint
+	VariantID   uint
+	Quantity    uint
+}
+
+// takeStock amount бірлікті қалдықтардан қоймалардың басымдығы бойынша алады (Warehouse
+// жүктелген болуы керек). Қалдықтар жетпесе, алынбай қалған саны да қайтарылады.
+func takeStock(stocks []WarehouseStock, amount uint) ([]stockTake, uint) {
+	ordered := append([]WarehouseStock(nil), stocks...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Warehouse.Priority != ordered[j].Warehouse.Priority {
+			return ordered[i].Warehouse.Priority < ordered[j].Warehouse.Priority
+		}
+		return ordered[i].WarehouseID < ordered[j].WarehouseID
+	})
+
+	var takes []stockTake
+	for _, stock := range ordered {
+		if amount == 0 {
+			break
+		}
+		quantity := min(amount, stock.Quantity)
+		if quantity == 0 {
+			continue
+		}
+		takes = append(takes, stockTake{WarehouseID: stock.WarehouseID, VariantID: stock.VariantID, Quantity: quantity})
+		amount -= quantity
+	}
+	return takes, amount
+}
+
+// splitDiscounts жолдың әлі бөлінбеген remaining бірлігіне тиесілі жеңілдіктердің quantity
+// бірлікке келетін үлесін бөліп алады. Соңғы бөлік қалғанның бәрін алады, сондықтан
+// бөліктердің қосындысы бастапқы жеңілдікке тең.
+func splitDiscounts(currency string, discounts []LineDiscount, quantity, remaining uint) (money.Money, []LineDiscount, money.Money, []LineDiscount) {
+	taken, rest := money.Zero(currency), money.Zero(currency)
+	takenDiscounts := make([]LineDiscount, 0, len(discounts))
+	restDiscounts := make([]LineDiscount, 0, len(discounts))
+	for _, discount := range discounts {
+		part := discount
+		part.Amount.Amount = discount.Amount.Amount * int64(quantity) / int64(remaining)
+		left := discount
+		left.Amount = discount.Amount.Sub(part.Amount)
+		takenDiscounts = append(takenDiscounts, part)
+		restDiscounts = append(restDiscounts, left)
+		taken = taken.Add(part.Amount)
+		rest = rest.Add(left.Amount)
+	}
+	return taken, takenDiscounts, rest, restDiscounts
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"slices"
+	"testing"
+
+	"NomadShop/money"
+)
+
+func TestSplitDiscounts(t *testing.T) {
+	discounts := []LineDiscount{
+		{Source: DiscountPromotion, SourceID: 1, Amount: money.New(1000, "KZT")},
+		{Source: DiscountCoupon, SourceID: 2, Amount: money.New(301, "KZT")},
+	}
+
+	tests := []struct {
+		name                string
+		discounts           []LineDiscount
+		quantity, remaining uint
+		taken, rest         []int64
+	}{
+		{name: "proportional share rounds down", discounts: discounts, quantity: 1, remaining: 3, taken: []int64{333, 100}, rest: []int64{667, 201}},
+		{name: "last part takes everything", discounts: discounts, quantity: 3, remaining: 3, taken: []int64{1000, 301}, rest: []int64{0, 0}},
+		{name: "no discounts", discounts: nil, quantity: 1, remaining: 2, taken: []int64{}, rest: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken, takenDiscounts, rest, restDiscounts := splitDiscounts("KZT", tt.discounts, tt.quantity, tt.remaining)
+			if got := discountAmounts(takenDiscounts); !equalInts(got, tt.taken) {
+				t.Errorf("taken discounts = %v, want %v", got, tt.taken)
+			}
+			if got := discountAmounts(restDiscounts); !equalInts(got, tt.rest) {
+				t.Errorf("rest discounts = %v, want %v", got, tt.rest)
+			}
+			if want := money.New(sumInts(tt.taken), "KZT"); taken != want {
+				t.Errorf("taken = %v, want %v", taken, want)
+			}
+			if want := money.New(sumInts(tt.rest), "KZT"); rest != want {
+				t.Errorf("rest = %v, want %v", rest, want)
+			}
+			for i, discount := range takenDiscounts {
+				if discount.SourceID != tt.discounts[i].SourceID || discount.Source != tt.discounts[i].Source {
+					t.Errorf("taken discount %d lost its source: %+v", i, discount)
+				}
+			}
+		})
+	}
+}
+
+// Жол бірнеше қоймаға бөлінгенде бөліктердің жеңілдіктері бастапқы жеңілдікке тең болады
+func TestSplitDiscountsAcrossWarehouses(t *testing.T) {
+	discounts := []LineDiscount{{Source: DiscountCoupon, SourceID: 1, Amount: money.New(1000, "KZT")}}
+	remaining := uint(7)
+	var parts []int64
+	for _, quantity := range []uint{3, 2, 2} {
+		var taken money.Money
+		taken, _, _, discounts = splitDiscounts("KZT", discounts, quantity, remaining)
+		parts = append(parts, taken.Amount)
+		remaining -= quantity
+	}
+	if want := []int64{428, 286, 286}; !equalInts(parts, want) {
+		t.Errorf("parts = %v, want %v", parts, want)
+	}
+	if discounts[0].Amount.Amount != 0 {
+		t.Errorf("undistributed discount = %v", discounts[0].Amount)
+	}
+}
+
+func TestTakeStock(t *testing.T) {
+	stock := func(warehouseID uint, priority int, quantity uint) WarehouseStock {
+		return WarehouseStock{WarehouseID: warehouseID, VariantID: 7, Quantity: quantity, Warehouse: Warehouse{ID: warehouseID, Priority: priority}}
+	}
+	// Басым қойма (priority 0) екінші жазба болса да бірінші алынады
+	stocks := []WarehouseStock{stock(2, 10, 4), stock(1, 0, 3), stock(3, 10, 5)}
+
+	tests := []struct {
+		name    string
+		amount  uint
+		takes   []stockTake
+		missing uint
+	}{
+		{"primary warehouse covers it", 2, []stockTake{{1, 7, 2}}, 0},
+		{"spills into the next warehouse", 5, []stockTake{{1, 7, 3}, {2, 7, 2}}, 0},
+		{"equal priority goes by id", 9, []stockTake{{1, 7, 3}, {2, 7, 4}, {3, 7, 2}}, 0},
+		{"not enough stock", 15, []stockTake{{1, 7, 3}, {2, 7, 4}, {3, 7, 5}}, 3},
+		{"nothing to take", 0, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takes, missing := takeStock(stocks, tt.amount)
+			if !slices.Equal(takes, tt.takes) || missing != tt.missing {
+				t.Errorf("takeStock(%d) = %v, %d, want %v, %d", tt.amount, takes, missing, tt.takes, tt.missing)
+			}
+		})
+	}
+	// Кіріс тізімінің реті өзгермейді
+	if stocks[0].WarehouseID != 2 {
+		t.Errorf("takeStock reordered its input: %+v", stocks)
+	}
+}
+
+func TestUpdateProductStockAcrossWarehouses(t *testing.T) {
+	db := testDB(t)
+	product := testProduct(t, db, 3)
+	primary, err := primaryWarehouseID(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var primaryWarehouse Warehouse
+	if err := db.First(&primaryWarehouse, primary).Error; err != nil {
+		t.Fatal(err)
+	}
+	second, err := CreateWarehouse(db, &Warehouse{Name: "Second warehouse", City: "Almaty", Priority: primaryWarehouse.Priority + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	movement := StockMovement{ProductID: product.ID, WarehouseID: second.ID, Delta: 4, Reason: MovementImport}
+	if err := ApplyStockMovement(db, &movement); err != nil {
+		t.Fatal(err)
+	}
+
+	// 7 бірліктен 2-ге: басым қоймадағы 3 бірлік, содан кейін екінші қоймадан 2 бірлік алынады
+	stock := uint(2)
+	if _, err := UpdateProduct(db, DefaultSettings, product.ID, &Product{}, &stock, 0); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+
+	stocks, err := GetWarehouseStocks(db, product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[uint]uint{}
+	for _, s := range stocks {
+		got[s.WarehouseID] = s.Quantity
+	}
+	if got[primary] != 0 || got[second.ID] != 2 {
+		t.Errorf("warehouse stock = %v, want primary 0 and second 2", got)
+	}
+	var updated Product
+	if err := db.First(&updated, product.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if updated.Stock != 2 {
+		t.Errorf("product stock = %d, want 2", updated.Stock)
+	}
+}
+
+func discountAmounts(discounts []LineDiscount) []int64 {
+	amounts := make([]int64, len(discounts))
+	for i, discount := range discounts {
+		amounts[i] = discount.Amount.Amount
+	}
+	return amounts
+}
+
+func sumInts(values []int64) int64 {
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
@@ -0,0 +1,231 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrNoRate          = errors.New("money: no exchange rate for currency")
+	ErrInvalidRate     = errors.New("money: exchange rate must be a positive decimal")
+	ErrUnknownRounding = errors.New("money: unknown rounding mode")
+)
+
+// Дөңгелектеу режимдері
+const (
+	RoundHalfUp   = "half_up"   // 0.5 және одан жоғары жоғарыға
+	RoundHalfEven = "half_even" // банктік дөңгелектеу
+	RoundUp       = "up"        // әрқашан жоғарыға
+	RoundDown     = "down"      // әрқашан төменге
+)
+
+// Rounding түрлендірілген соманы дөңгелектеу ережесі. Steps валютаның ең кіші
+// бірліктеріндегі қадам, мысалы {"RUB": 100} рубльді бүтін санға дейін дөңгелектейді.
+// Қадамы көрсетілмеген валюталар ең кіші бірлікке дейін дөңгелектенеді.
+type Rounding struct {
+	Mode  string
+	Steps map[string]int64
+}
+
+func (r Rounding) Validate() error {
+	switch r.Mode {
+	case RoundHalfUp, RoundHalfEven, RoundUp, RoundDown:
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownRounding, r.Mode)
+	}
+	for currency, step := range r.Steps {
+		if _, err := Exponent(currency); err != nil {
+			return err
+		}
+		if step <= 0 {
+			return fmt.Errorf("money: rounding step for %s must be positive", currency)
+		}
+	}
+	return nil
+}
+
+// ParseRate "487.50" түріндегі бағамды оқиды
+func ParseRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || rate.Sign() <= 0 || strings.ContainsAny(s, "/eE") {
+		return nil, fmt.Errorf("%w, got %q", ErrInvalidRate, s)
+	}
+	return rate, nil
+}
+
+// Converter сомаларды негізгі валюта арқылы түрлендіреді. Rates[c] — c валютасының
+// бір бірлігі негізгі валютаның қанша бірлігіне тең (1 USD = 487.50 KZT).
+type Converter struct {
+	Base     string
+	Rates    map[string]*big.Rat
+	Rounding Rounding
+}
+
+// Rate валютаның негізгі валютаға қатысты бағамы. Негізгі валютаның бағамы 1.
+func (c *Converter) Rate(currency string) (*big.Rat, error) {
+	if currency == c.Base {
+		return big.NewRat(1, 1), nil
+	}
+	rate, ok := c.Rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoRate, currency)
+	}
+	return rate, nil
+}
+
+// Convert m сомасын to валютасына түрлендіреді және Rounding ережесімен дөңгелектейді
+func (c *Converter) Convert(m Money, to string) (Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+	fromExp, err := Exponent(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toExp, err := Exponent(to)
+	if err != nil {
+		return Money{}, err
+	}
+	fromRate, err := c.Rate(m.Currency)
+	if err != nil {
+		return Money{}, err
+	}
+	toRate, err := c.Rate(to)
+	if err != nil {
+		return Money{}, err
+	}
+
+	// amount / 10^fromExp * fromRate / toRate * 10^toExp, барлығы бөлшек сандармен
+	value := new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(fromExp))
+	value.Mul(value, fromRate)
+	value.Quo(value, toRate)
+	value.Mul(value, new(big.Rat).SetInt(pow10(toExp)))
+
+	step := c.Rounding.Steps[to]
+	if step <= 0 {
+		step = 1
+	}
+	amount, err := round(value, step, c.Rounding.Mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: to}, nil
+}
+
+// ConvertAll v ішіндегі (құрылымдар, кесінділер, көрсеткіштер арқылы) from валютасындағы
+// барлық Money мәндерін to валютасына түрлендіреді. Басқа валютадағы сомалар, мысалы
+// тапсырыстың төленген сомасы, өзгеріссіз қалады. v көрсеткіш болуы керек.
+func (c *Converter) ConvertAll(v interface{}, from, to string) error {
+	return walk(reflect.ValueOf(v), func(m *Money) error {
+		if m.Currency != from {
+			return nil
+		}
+		converted, err := c.Convert(*m, to)
+		if err != nil {
+			return err
+		}
+		*m = converted
+		return nil
+	})
+}
+
+var moneyType = reflect.TypeOf(Money{})
+
+func walk(v reflect.Value, fn func(*Money) error) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walk(v.Elem(), fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Pointer || !v.CanSet() {
+			return walk(elem, fn)
+		}
+		// Интерфейс ішіндегі мән өзгертілмейді, сондықтан көшірмесі түрлендіріліп, қайта қойылады
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		if err := walk(copied, fn); err != nil {
+			return err
+		}
+		v.Set(copied)
+	case reflect.Map:
+		// gin.H сияқты жауаптар үшін
+		iter := v.MapRange()
+		for iter.Next() {
+			copied := reflect.New(iter.Value().Type()).Elem()
+			copied.Set(iter.Value())
+			if err := walk(copied, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), copied)
+		}
+	case reflect.Struct:
+		if v.Type() == moneyType {
+			if !v.CanAddr() {
+				return nil
+			}
+			return fn(v.Addr().Interface().(*Money))
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := walk(v.Field(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := walk(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func round(value *big.Rat, step int64, mode string) (int64, error) {
+	units := new(big.Rat).Quo(value, new(big.Rat).SetInt64(step))
+	quo, rem := new(big.Int).QuoRem(units.Num(), units.Denom(), new(big.Int))
+
+	if rem.Sign() != 0 {
+		// |rem| / denom бөлшегін 1/2-мен салыстыру
+		twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+		half := twice.Cmp(units.Denom())
+		away := false
+		switch mode {
+		case RoundHalfUp:
+			away = half >= 0
+		case RoundHalfEven:
+			away = half > 0 || (half == 0 && quo.Bit(0) == 1)
+		case RoundUp:
+			away = rem.Sign() > 0
+		case RoundDown:
+			away = false
+		default:
+			return 0, fmt.Errorf("%w %q", ErrUnknownRounding, mode)
+		}
+		if mode == RoundDown && rem.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else if away {
+			quo.Add(quo, big.NewInt(int64(rem.Sign())))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: converted amount overflows", ErrInvalidAmount)
+	}
+	return quo.Int64() * step, nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
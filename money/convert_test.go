@@ -0,0 +1,209 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value string
+		step  int64
+		mode  string
+		want  int64
+	}{
+		{"2.5", 1, RoundHalfUp, 3},
+		{"-2.5", 1, RoundHalfUp, -3},
+		{"2.49", 1, RoundHalfUp, 2},
+		{"2.5", 1, RoundHalfEven, 2},
+		{"3.5", 1, RoundHalfEven, 4},
+		{"-2.5", 1, RoundHalfEven, -2},
+		{"2.51", 1, RoundHalfEven, 3},
+		{"2.01", 1, RoundUp, 3},
+		{"-2.99", 1, RoundUp, -2},
+		{"2.99", 1, RoundDown, 2},
+		{"-2.01", 1, RoundDown, -3},
+		{"7", 1, RoundHalfUp, 7},
+		// Қадам бойынша: 100 тиын = 1 рубль
+		{"12350", 100, RoundHalfUp, 12400},
+		{"12349", 100, RoundHalfUp, 12300},
+		{"12301", 100, RoundUp, 12400},
+		{"12399", 100, RoundDown, 12300},
+		{"-12350", 100, RoundHalfEven, -12400},
+	}
+
+	for _, tt := range tests {
+		value, _ := new(big.Rat).SetString(tt.value)
+		got, err := round(value, tt.step, tt.mode)
+		if err != nil {
+			t.Errorf("round(%s, %d, %s) error = %v", tt.value, tt.step, tt.mode, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("round(%s, %d, %s) = %d, want %d", tt.value, tt.step, tt.mode, got, tt.want)
+		}
+	}
+
+	if _, err := round(big.NewRat(1, 3), 1, "nearest"); !errors.Is(err, ErrUnknownRounding) {
+		t.Errorf("unknown mode error = %v", err)
+	}
+	huge, _ := new(big.Rat).SetString("100000000000000000000")
+	if _, err := round(huge, 1, RoundHalfUp); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("overflow error = %v", err)
+	}
+}
+
+func testConverter(mode string, steps map[string]int64) *Converter {
+	rate := func(s string) *big.Rat {
+		r, err := ParseRate(s)
+		if err != nil {
+			panic(err)
+		}
+		return r
+	}
+	return &Converter{
+		Base: "KZT",
+		Rates: map[string]*big.Rat{
+			"USD": rate("487.50"),
+			"RUB": rate("5.3"),
+			"JPY": rate("3.25"),
+			"KWD": rate("1580"),
+		},
+		Rounding: Rounding{Mode: mode, Steps: steps},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name      string
+		converter *Converter
+		from      Money
+		to        string
+		want      Money
+		err       error
+	}{
+		{"same currency", testConverter(RoundHalfUp, nil), New(1999, "USD"), "USD", New(1999, "USD"), nil},
+		{"base to foreign", testConverter(RoundHalfUp, nil), New(4875000, "KZT"), "USD", New(10000, "USD"), nil},
+		{"foreign to base", testConverter(RoundHalfUp, nil), New(1999, "USD"), "KZT", New(974513, "KZT"), nil},
+		{"half up", testConverter(RoundHalfUp, nil), New(100000, "KZT"), "USD", New(205, "USD"), nil},
+		{"down", testConverter(RoundDown, nil), New(100000, "KZT"), "USD", New(205, "USD"), nil},
+		{"up", testConverter(RoundUp, nil), New(100000, "KZT"), "USD", New(206, "USD"), nil},
+		{"cross rate through base", testConverter(RoundHalfUp, nil), New(10000, "USD"), "RUB", New(919811, "RUB"), nil},
+		{"zero exponent target", testConverter(RoundHalfUp, nil), New(100000, "KZT"), "JPY", New(308, "JPY"), nil},
+		{"three digit exponent", testConverter(RoundHalfUp, nil), New(100000, "KZT"), "KWD", New(633, "KWD"), nil},
+		{"step rounding", testConverter(RoundHalfUp, map[string]int64{"RUB": 100}), New(10000, "USD"), "RUB", New(919800, "RUB"), nil},
+		{"step rounding up", testConverter(RoundUp, map[string]int64{"RUB": 100}), New(10000, "USD"), "RUB", New(919900, "RUB"), nil},
+		{"negative amount", testConverter(RoundHalfUp, nil), New(-100000, "KZT"), "USD", New(-205, "USD"), nil},
+		{"missing rate", testConverter(RoundHalfUp, nil), New(100, "KZT"), "EUR", Money{}, ErrNoRate},
+		{"unknown currency", testConverter(RoundHalfUp, nil), New(100, "KZT"), "XXX", Money{}, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.converter.Convert(tt.from, tt.to)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Convert(%v, %s) error = %v, want %v", tt.from, tt.to, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Convert(%v, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertAll(t *testing.T) {
+	type line struct {
+		Price    Money
+		Discount *Money
+	}
+	type order struct {
+		Total Money
+		Paid  Money
+		Lines []line
+		Extra map[string]interface{}
+		Note  interface{}
+	}
+
+	discount := New(97500, "KZT")
+	in := order{
+		Total: New(487500, "KZT"),
+		Paid:  New(1000, "USD"),
+		Lines: []line{{Price: New(195000, "KZT"), Discount: &discount}},
+		Extra: map[string]interface{}{"shipping": New(48750, "KZT"), "count": 3},
+		Note:  New(4875, "KZT"),
+	}
+
+	if err := testConverter(RoundHalfUp, nil).ConvertAll(&in, "KZT", "USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"struct field", in.Total, New(1000, "USD")},
+		{"other currency untouched", in.Paid, New(1000, "USD")},
+		{"slice element", in.Lines[0].Price, New(400, "USD")},
+		{"pointer", *in.Lines[0].Discount, New(200, "USD")},
+		{"map value", in.Extra["shipping"].(Money), New(100, "USD")},
+		{"interface value", in.Note.(Money), New(10, "USD")},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if in.Extra["count"] != 3 {
+		t.Errorf("non-money map value changed: %v", in.Extra["count"])
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{"487.50", "975/2", false},
+		{" 1 ", "1", false},
+		{"0", "", true},
+		{"-5", "", true},
+		{"1/3", "", true},
+		{"1e3", "", true},
+		{"abc", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRate(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseRate(%q) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && got.RatString() != tt.want {
+			t.Errorf("ParseRate(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) error = %v, want ErrInvalidRate", tt.in, err)
+		}
+	}
+}
+
+func TestRoundingValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding Rounding
+		ok       bool
+	}{
+		{"half up", Rounding{Mode: RoundHalfUp}, true},
+		{"with steps", Rounding{Mode: RoundHalfEven, Steps: map[string]int64{"RUB": 100}}, true},
+		{"unknown mode", Rounding{Mode: "nearest"}, false},
+		{"unknown currency step", Rounding{Mode: RoundUp, Steps: map[string]int64{"XXX": 100}}, false},
+		{"zero step", Rounding{Mode: RoundDown, Steps: map[string]int64{"RUB": 0}}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.rounding.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package money ақша сомаларын валютаның ең кіші бірлігімен (тиын, цент) бүтін сан ретінде
+// сақтайды, сондықтан float64-тегідей дөңгелектеу қателері болмайды.
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("money: unknown currency")
+	ErrInvalidAmount    = errors.New("money: invalid amount")
+	ErrCurrencyMismatch = errors.New("money: currency mismatch")
+)
+
+// maxExponent exponents ішіндегі ең үлкен мән: валютасы белгісіз сома осымен тексеріледі
+const maxExponent = 3
+
+// exponents ISO 4217 валюталарының ондық таңба саны
+var exponents = map[string]int{
+	"KZT": 2,
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CNY": 2,
+	"TRY": 2,
+	"UZS": 2,
+	"KGS": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+}
+
+// Money сома және оның валютасы. Базада екі баған ретінде сақталады:
+// Price money.Money `gorm:"embedded;embeddedPrefix:price_"` -> price_amount, price_currency.
+type Money struct {
+	Amount   int64  `gorm:"not null"` // ең кіші бірлікпен, мысалы тиын
+	Currency string `gorm:"type:char(3);not null"`
+
+	// unresolved валютасы көрсетілмеген JSON мәнінің ондық жолы. Ондай сома
+	// Resolve арқылы дүкеннің негізгі валютасында оқылғанша қолданылмайды.
+	unresolved string
+}
+
+func New(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Zero берілген валютадағы нөл сома
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// Exponent валютаның ондық таңба саны
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnknownCurrency, currency)
+	}
+	return exp, nil
+}
+
+// Scale валютаның бір бірлігіндегі ең кіші бірліктер саны (KZT үшін 100)
+func Scale(currency string) (int64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Pow10(exp)), nil
+}
+
+// Parse "1250.50" түріндегі ондық жолды оқиды. Валюта рұқсат ететіннен көп
+// ондық таңба болса, сома дөңгелектенбейді, қате қайтарылады.
+func Parse(s, currency string) (Money, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	amount, err := parseDecimal(s, exp)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// parseDecimal ондық жолды exp ондық таңбасы бар ең кіші бірліктерге айналдырады
+func parseDecimal(s string, exp int) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || len(frac) > exp || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// Resolve валютасы көрсетілмеген соманы currency валютасында деп есептейді: JSON-дағы
+// "1250.50" сол валютада оқылады, ал бос Money{} сол валютадағы нөлге айналады.
+// Валютасы бар сома өзгеріссіз қайтарылады.
+func (m Money) Resolve(currency string) (Money, error) {
+	switch {
+	case m.unresolved != "":
+		return Parse(m.unresolved, currency)
+	case m.Currency == "" && m.Amount == 0:
+		return Zero(currency), nil
+	}
+	return m, nil
+}
+
+// Decimal соманы ондық жол ретінде қайтарады, мысалы "1250.50"
+func (m Money) Decimal() string {
+	exp := exponents[m.Currency]
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add екі соманы қосады. Валютасыз нөл сома (Money{}) кез келген валютамен қосыла алады,
+// сондықтан жиынтықты бос мәннен бастауға болады. Әртүрлі валюталар қосылса, panic болады:
+// бұл бағдарламаның қатесі, валюталарды алдын ала теңестіру керек.
+func (m Money) Add(other Money) Money {
+	currency := m.mustMatch(other)
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+func (m Money) Sub(other Money) Money {
+	currency := m.mustMatch(other)
+	return Money{Amount: m.Amount - other.Amount, Currency: currency}
+}
+
+// Mul соманы санға көбейтеді (мысалы, баға × саны)
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// Cmp m < other болса -1, тең болса 0, үлкен болса 1 қайтарады
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+	switch {
+	case m.Amount < other.Amount:
+		return -1
+	case m.Amount > other.Amount:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Sum сомалардың жиынтығы. Тізім бос болса, currency валютасындағы нөл қайтарылады.
+func Sum(currency string, amounts ...Money) Money {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+	return total
+}
+
+func (m Money) mustMatch(other Money) string {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("%v: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency))
+}
+
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON {"amount":"1250.50","currency":"KZT"} түрінде жазады
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON {"amount":"1250.50","currency":"KZT"} объектісін, сондай-ақ негізгі
+// валютадағы "1250.50" жолын немесе 1250.5 санын қабылдайды. Валютасы жоқ мәндер
+// Resolve шақырылғанша шешілмеген күйде қалады.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var raw jsonMoney
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		var object struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency string          `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		raw.Currency = object.Currency
+		raw.Amount = strings.Trim(string(object.Amount), `"`)
+	case len(data) > 0 && data[0] == '"':
+		if err := json.Unmarshal(data, &raw.Amount); err != nil {
+			return err
+		}
+	default:
+		// Санның мәтіндік түрі қолданылады, сондықтан float64 арқылы өтпейді
+		raw.Amount = string(data)
+	}
+
+	// Негізгі валюта тек модельдерге белгілі, сондықтан мұнда тек сома тексеріледі
+	if raw.Currency == "" {
+		if _, err := parseDecimal(raw.Amount, maxExponent); err != nil {
+			return err
+		}
+		*m = Money{unresolved: strings.TrimSpace(raw.Amount)}
+		return nil
+	}
+	parsed, err := Parse(raw.Amount, strings.ToUpper(raw.Currency))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
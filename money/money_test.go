@@ -0,0 +1,206 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     Money
+		err      error
+	}{
+		{"1250.50", "KZT", New(125050, "KZT"), nil},
+		{"1250.5", "KZT", New(125050, "KZT"), nil},
+		{"1250", "KZT", New(125000, "KZT"), nil},
+		{" 0.07 ", "USD", New(7, "USD"), nil},
+		{"-3.10", "EUR", New(-310, "EUR"), nil},
+		{"1500", "JPY", New(1500, "JPY"), nil},
+		{"1.234", "KWD", New(1234, "KWD"), nil},
+		{"1.005", "KZT", Money{}, ErrInvalidAmount},
+		{"1.5", "JPY", Money{}, ErrInvalidAmount},
+		{"", "KZT", Money{}, ErrInvalidAmount},
+		{".50", "KZT", Money{}, ErrInvalidAmount},
+		{"--1", "KZT", Money{}, ErrInvalidAmount},
+		{"+1", "KZT", Money{}, ErrInvalidAmount},
+		{"1.-5", "KZT", Money{}, ErrInvalidAmount},
+		{"1e3", "KZT", Money{}, ErrInvalidAmount},
+		{"12,50", "KZT", Money{}, ErrInvalidAmount},
+		{"99999999999999999999", "KZT", Money{}, ErrInvalidAmount},
+		{"10", "XXX", Money{}, ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.currency)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Parse(%q, %s) error = %v, want %v", tt.in, tt.currency, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %s) = %#v, want %#v", tt.in, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{New(125050, "KZT"), "1250.50"},
+		{New(5, "KZT"), "0.05"},
+		{New(0, "KZT"), "0.00"},
+		{New(-5, "USD"), "-0.05"},
+		{New(-125000, "USD"), "-1250.00"},
+		{New(1500, "JPY"), "1500"},
+		{New(7, "KWD"), "0.007"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Decimal(); got != tt.want {
+			t.Errorf("%#v.Decimal() = %q, want %q", tt.money, got, tt.want)
+		}
+		// Decimal пен Parse бір-біріне кері
+		if back, err := Parse(tt.want, tt.money.Currency); err != nil || back != tt.money {
+			t.Errorf("Parse(%q) = %#v, %v, want %#v", tt.want, back, err, tt.money)
+		}
+	}
+	if got := New(125050, "KZT").String(); got != "1250.50 KZT" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"add", New(150, "KZT").Add(New(50, "KZT")), New(200, "KZT")},
+		{"sub below zero", New(150, "KZT").Sub(New(200, "KZT")), New(-50, "KZT")},
+		{"add to empty zero", Money{}.Add(New(50, "USD")), New(50, "USD")},
+		{"sub empty zero", New(50, "USD").Sub(Money{}), New(50, "USD")},
+		{"mul", New(1999, "KZT").Mul(3), New(5997, "KZT")},
+		{"sum", Sum("KZT", New(1, "KZT"), New(2, "KZT"), New(3, "KZT")), New(6, "KZT")},
+		{"empty sum", Sum("EUR"), Zero("EUR")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %#v, want %#v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	cmps := []struct {
+		a, b Money
+		want int
+	}{
+		{New(1, "KZT"), New(2, "KZT"), -1},
+		{New(2, "KZT"), New(2, "KZT"), 0},
+		{New(3, "KZT"), New(2, "KZT"), 1},
+		{Money{}, New(-1, "KZT"), 1},
+	}
+	for _, tt := range cmps {
+		if got := tt.a.Cmp(tt.b); got != tt.want {
+			t.Errorf("%v.Cmp(%v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"add", func() { New(1, "KZT").Add(New(1, "USD")) }},
+		{"sub", func() { New(1, "KZT").Sub(New(1, "USD")) }},
+		{"cmp", func() { New(1, "KZT").Cmp(New(1, "USD")) }},
+		// Валютасыз тек нөл сома кез келген валютамен үйлеседі
+		{"non-zero without currency", func() { Money{Amount: 1}.Add(New(1, "USD")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(New(125050, "KZT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"1250.50","currency":"KZT"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	tests := []struct {
+		in   string
+		want Money // валютасыз мәндер KZT-де шешілгеннен кейін
+		err  bool
+	}{
+		{`{"amount":"1250.50","currency":"KZT"}`, New(125050, "KZT"), false},
+		{`{"amount":19.99,"currency":"usd"}`, New(1999, "USD"), false},
+		{`{"amount":"10"}`, New(1000, "KZT"), false},
+		{`"1250.50"`, New(125050, "KZT"), false},
+		{`1250.5`, New(125050, "KZT"), false},
+		{`0.1`, New(10, "KZT"), false},
+		{`null`, Zero("KZT"), false},
+		{`"1.001"`, Money{}, true},
+		{`"1.0001"`, Money{}, true},
+		{`"abc"`, Money{}, true},
+		{`{"amount":"1","currency":"XXX"}`, Money{}, true},
+		{`true`, Money{}, true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if err == nil {
+			got, err = got.Resolve("KZT")
+		}
+		if (err != nil) != tt.err {
+			t.Errorf("Unmarshal(%s) error = %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if !tt.err && got != tt.want {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	var bare Money
+	if err := json.Unmarshal([]byte(`"100"`), &bare); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		money    Money
+		currency string
+		want     Money
+		err      bool
+	}{
+		{"bare amount in base currency", bare, "KZT", New(10000, "KZT"), false},
+		{"bare amount in zero-exponent currency", bare, "JPY", New(100, "JPY"), false},
+		{"empty money becomes zero", Money{}, "USD", Zero("USD"), false},
+		{"explicit currency is kept", New(500, "USD"), "KZT", New(500, "USD"), false},
+		{"unknown currency", bare, "XXX", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.money.Resolve(tt.currency)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: Resolve() error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if !tt.err && got != tt.want {
+			t.Errorf("%s: Resolve() = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Log хабарламаларды жібермей, тек журналға жазады
+type Log struct{}
+
+func (Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("Notification %s to user %d <%s>: %s\n%s", msg.Kind, msg.UserID, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,20 @@
+// Package notify пайдаланушыларға хабарлама жіберу қабаты. Әзірлеу үшін хабарламалар
+// журналға немесе файлға жазылады; email не SMS провайдері осы интерфейсті іске асырады.
+package notify
+
+import "context"
+
+// Message бір пайдаланушыға жіберілетін хабарлама
+type Message struct {
+	UserID  uint   `json:"user_id"`
+	To      string `json:"to"`   // email мекенжайы
+	Kind    string `json:"kind"` // мысалы "cart_reminder"
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Key     string `json:"key,omitempty"` // бір кілтпен қайта жіберілген хабарламаны провайдер бір рет жеткізеді
+}
+
+// Notifier хабарламаны жеткізеді. Қате қайтарылса, хабарлама кейін қайта жіберіледі.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,21 @@
+// Package storage жүктелген файлдарды (өнім суреттері т.б.) сақтау қабаты.
+// Әзірге жергілікті файл жүйесі қолданылады; S3-үйлесімді қойма осы интерфейсті іске асырады.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage файлдарды кілт бойынша сақтайды. Кілт "/" арқылы бөлінген салыстырмалы жол,
+// мысалы products/12/3f9a/original.jpg.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL клиентке берілетін мекенжай
+	URL(key string) string
+}